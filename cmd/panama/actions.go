@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/shell"
+	"github.com/yuya-takeyama/panama/internal/ui/fuzzyfinder"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// acceptAction is the name reported with --print-action for Enter
+const acceptAction = "accept"
+
+// actionKeys returns the keys bound to actions
+func actionKeys(actions []config.Action) []string {
+	keys := make([]string, len(actions))
+	for i, action := range actions {
+		keys[i] = action.Key
+	}
+	return keys
+}
+
+// actionHeader returns the key binding hints shown above the finder list
+func actionHeader(actions []config.Action) string {
+	hints := make([]string, 0, len(actions))
+	for _, action := range actions {
+		if action.Name != "" {
+			hints = append(hints, action.Key+": "+action.Name)
+		}
+	}
+	return strings.Join(hints, "  ")
+}
+
+// findAction returns the action bound to key, as reported by the finder.
+// When a key is bound more than once, such as by an extended file and the
+// file extending it, the last binding wins.
+func findAction(actions []config.Action, key string) *config.Action {
+	if key == "" {
+		return nil
+	}
+	for i := len(actions) - 1; i >= 0; i-- {
+		if name, err := fuzzyfinder.NormalizeKey(actions[i].Key); err == nil && name == key {
+			return &actions[i]
+		}
+	}
+	return nil
+}
+
+// editor returns the editor command preferred for ws, falling back to
+// $VISUAL, $EDITOR and vi
+func editor(ws *workspace.Workspace) string {
+	return cmp.Or(ws.Editor, os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi")
+}
+
+// runAction runs the action's command in each workspace, attached to the
+// terminal so that editors and shells work while stdout is captured
+func runAction(action *config.Action, workspaces []*workspace.Workspace) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		tty = nil
+	} else {
+		defer tty.Close()
+	}
+
+	for _, ws := range workspaces {
+		cmd := shell.Command(context.Background(), shell.Expand(action.Run, ws.Path))
+		cmd.Dir = ws.Path
+		cmd.Env = append(os.Environ(), "PANAMA_WORKSPACE="+ws.Path, "PANAMA_ACTION="+action.Label(), "PANAMA_EDITOR="+editor(ws))
+		if tty != nil {
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = tty, tty, tty
+		} else {
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+		}
+
+		if err := cmd.Run(); err != nil {
+			if !action.Return {
+				return fmt.Errorf("action %s failed in %s: %w", action.Label(), ws.Path, err)
+			}
+			fmt.Fprintf(cmd.Stderr, "\naction %s failed in %s: %v\n", action.Label(), ws.Path, err)
+		}
+	}
+
+	// Keep the output on screen until the finder is shown again
+	if action.Return && tty != nil {
+		fmt.Fprint(tty, "\n[Press Enter to return to panama]")
+		_, _ = bufio.NewReader(tty).ReadString('\n')
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/yuya-takeyama/panama/internal/changes"
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/output"
+	"github.com/yuya-takeyama/panama/internal/pipeline"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+type affectedOptions struct {
+	since    string
+	format   string
+	maxDepth int
+	noCache  bool
+	config   string
+	silent   bool
+}
+
+func newAffectedCommand() *cobra.Command {
+	opts := &affectedOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "affected [path]",
+		Short: "List the workspaces touched by git changes",
+		Long: `List the workspaces containing files changed since the merge base of a git
+ref and HEAD, including uncommitted and untracked files. Without --since,
+affected.base from the configuration is used, and then HEAD.
+
+Each file belongs to the innermost workspace containing it. Files in no
+workspace are reported on stderr, and in the JSON output. Changes to files
+matching affected.global, such as lock files, affect every workspace.`,
+		Example: `  panama affected --since origin/main
+  panama affected --since origin/main -f nul | xargs -0 -I{} make -C {} test
+  panama affected -f json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAffected(args, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.since, "since", "", "Git ref to compare against (overrides affected.base)")
+	flags.StringVarP(&opts.format, "format", "f", "path", "Output format (path|json|nul)")
+	flags.IntVar(&opts.maxDepth, "max-depth", 0, "Maximum search depth (0 uses config default)")
+	flags.BoolVar(&opts.noCache, "no-cache", false, "Disable caching")
+	flags.StringVar(&opts.config, "config", "", "Path to configuration file")
+	flags.BoolVar(&opts.silent, "silent", false, "Do not report files in no workspace")
+
+	return cmd
+}
+
+func runAffected(args []string, opts *affectedOptions) error {
+	rootDir := "."
+	if len(args) > 0 {
+		rootDir = args[0]
+	}
+	absRoot, err := filepath.Abs(rootDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	cfg := config.Load(opts.config, absRoot)
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	format, err := output.ParseFormat(opts.format)
+	if err != nil {
+		return err
+	}
+	if format == output.FormatCD {
+		return fmt.Errorf("format cd is not supported for affected")
+	}
+
+	searchRoot := absRoot
+	if cfg.ConfigDir != "" {
+		searchRoot = cfg.ConfigDir
+	}
+
+	result, err := pipeline.Collect(searchRoot, cfg, pipeline.Options{MaxDepth: opts.maxDepth, NoCache: opts.noCache})
+	if err != nil {
+		return fmt.Errorf("failed to collect workspaces: %w", err)
+	}
+
+	affected, err := affectedWorkspaces(searchRoot, opts.since, cfg, result.Workspaces)
+	if err != nil {
+		return err
+	}
+
+	if format == output.FormatJSON {
+		describeAll(affected.Workspaces, pipeline.NewDescriber(cfg), workspace.AllDetails)
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(affected)
+	}
+
+	if !opts.silent && !cfg.Silent {
+		if len(affected.Global) > 0 {
+			fmt.Fprintf(os.Stderr, "%s changed, so every workspace is affected\n", affected.Global[0])
+		}
+		if len(affected.Unowned) > 0 {
+			fmt.Fprintf(os.Stderr, "warning: %d changed files belong to no workspace:\n", len(affected.Unowned))
+			for _, file := range affected.Unowned {
+				fmt.Fprintf(os.Stderr, "  %s\n", file)
+			}
+		}
+	}
+	return printWorkspaces(affected.Workspaces, format, pipeline.NewDescriber(cfg))
+}
+
+// affectedWorkspaces maps the files changed since the merge base of ref, or
+// of affected.base without one, to the workspaces
+func affectedWorkspaces(searchRoot, ref string, cfg *config.Config, workspaces []*workspace.Workspace) (*changes.Affected, error) {
+	tree := changes.WorkTree(searchRoot)
+	if tree == "" {
+		return nil, fmt.Errorf("%s is not inside a git work tree", searchRoot)
+	}
+	if ref == "" {
+		ref = cfg.Affected.Base
+	}
+	files, err := changes.Files(tree, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files: %w", err)
+	}
+	for i, file := range files {
+		files[i] = filepath.Join(tree, filepath.FromSlash(file))
+	}
+	return changes.Map(searchRoot, files, workspaces, cfg.Affected.Global), nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestAffected(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tmpDir := setupWorkspaces(t, "apps/web", "services/api")
+	config := "patterns:\n  - go.mod\naffected:\n  global:\n    - go.work\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".panama.yaml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", tmpDir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	git("init", "-q", "-b", "main")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+	git("checkout", "-q", "-b", "feature")
+	if err := os.WriteFile(filepath.Join(tmpDir, "services", "api", "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "-A")
+	git("commit", "-q", "-m", "api")
+
+	affected := func(opts *affectedOptions) string {
+		t.Helper()
+		opts.noCache = true
+		if opts.format == "" {
+			opts.format = "path"
+		}
+		out, err := captureStdout(t, func() error { return runAffected(nil, opts) })
+		if err != nil {
+			t.Fatalf("runAffected() error = %v", err)
+		}
+		return out
+	}
+
+	if got, want := affected(&affectedOptions{since: "main"}), filepath.Join(tmpDir, "services", "api")+"\n"; got != want {
+		t.Errorf("--since main = %q, want %q", got, want)
+	}
+	if got := affected(&affectedOptions{}); got != "" {
+		t.Errorf("without changes = %q, want nothing", got)
+	}
+
+	// The root is a workspace too, since it holds the git repository
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.work"), []byte("go 1.22\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want := tmpDir + "\n" + filepath.Join(tmpDir, "apps", "web") + "\n" + filepath.Join(tmpDir, "services", "api") + "\n"
+	if got := affected(&affectedOptions{}); got != want {
+		t.Errorf("global file changed = %q, want %q", got, want)
+	}
+}
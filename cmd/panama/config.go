@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yuya-takeyama/panama/internal/config"
+)
+
+type configSchemaOptions struct {
+	output string
+}
+
+func newConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the panama configuration format",
+	}
+
+	cmd.AddCommand(newConfigSchemaCommand())
+
+	return cmd
+}
+
+func newConfigSchemaCommand() *cobra.Command {
+	opts := &configSchemaOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema for the configuration file",
+		Long: `Print the JSON Schema describing .panama.yaml.
+Editors with YAML language server support use it for completion and validation.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigSchema(opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.output, "output", "o", "", "Write the schema to a file instead of stdout")
+
+	return cmd
+}
+
+func runConfigSchema(opts *configSchemaOptions) error {
+	data, err := config.SchemaJSON()
+	if err != nil {
+		return fmt.Errorf("failed to generate schema: %w", err)
+	}
+
+	if opts.output == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(opts.output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+	return nil
+}
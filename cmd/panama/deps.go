@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yuya-takeyama/panama/internal/changes"
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/graph"
+	"github.com/yuya-takeyama/panama/internal/output"
+	"github.com/yuya-takeyama/panama/internal/pipeline"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+type depsOptions struct {
+	format     string
+	depth      int
+	maxDepth   int
+	noCache    bool
+	config     string
+	cycles     bool
+	dependents bool
+}
+
+func newDepsCommand() *cobra.Command {
+	opts := &depsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "deps [workspace]",
+		Short: "List the workspaces a workspace depends on",
+		Long: `List the workspaces that a workspace depends on, nearest first, as declared
+in package.json, go.mod, Cargo.toml, pyproject.toml and Chart.yaml. The
+workspace is given by path, relative path or name, and defaults to the one
+containing the current directory.
+
+With --cycles, list the groups of workspaces depending on each other
+instead, and exit with an error when there are any.`,
+		Example: `  panama deps
+  panama deps services/api --depth 1
+  panama deps --cycles`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeps(args, opts)
+		},
+	}
+	addDepsFlags(cmd, opts)
+	cmd.Flags().BoolVar(&opts.cycles, "cycles", false, "List dependency cycles instead")
+
+	return cmd
+}
+
+func newDependentsCommand() *cobra.Command {
+	opts := &depsOptions{dependents: true}
+
+	cmd := &cobra.Command{
+		Use:   "dependents [workspace]",
+		Short: "List the workspaces depending on a workspace",
+		Long: `List the workspaces that depend on a workspace, nearest first: those that
+may break when it changes. The workspace is given by path, relative path or
+name, and defaults to the one containing the current directory.`,
+		Example: `  panama dependents libs/auth
+  panama dependents libs/auth -f nul | xargs -0 -I{} make -C {} test`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeps(args, opts)
+		},
+	}
+	addDepsFlags(cmd, opts)
+
+	return cmd
+}
+
+func addDepsFlags(cmd *cobra.Command, opts *depsOptions) {
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.format, "format", "f", "path", "Output format (path|json|nul)")
+	flags.IntVar(&opts.depth, "depth", 0, "Follow dependencies this many steps away (0 follows all)")
+	flags.IntVar(&opts.maxDepth, "max-depth", 0, "Maximum search depth (0 uses config default)")
+	flags.BoolVar(&opts.noCache, "no-cache", false, "Disable caching")
+	flags.StringVar(&opts.config, "config", "", "Path to configuration file")
+}
+
+func runDeps(args []string, opts *depsOptions) error {
+	format, err := output.ParseFormat(opts.format)
+	if err != nil {
+		return err
+	}
+	if format == output.FormatCD {
+		return fmt.Errorf("format cd is not supported for deps and dependents")
+	}
+
+	g, cfg, searchRoot, err := buildGraph(opts.config, opts.maxDepth, opts.noCache)
+	if err != nil {
+		return err
+	}
+
+	if opts.cycles {
+		return printCycles(g.Cycles(), searchRoot, format)
+	}
+
+	target := ""
+	if len(args) > 0 {
+		target = args[0]
+	}
+	describer := pipeline.NewDescriber(cfg)
+	ws, err := findWorkspace(g.Workspaces(), target, searchRoot, describer)
+	if err != nil {
+		return err
+	}
+
+	found := g.Dependencies(ws, opts.depth)
+	if opts.dependents {
+		found = g.Dependents(ws, opts.depth)
+	}
+	return printWorkspaces(found, format, describer)
+}
+
+// buildGraph collects the workspaces below the configuration root, or the
+// git work tree without one, and builds their dependency graph
+func buildGraph(configPath string, maxDepth int, noCache bool) (*graph.Graph, *config.Config, string, error) {
+	cwd, err := filepath.Abs(".")
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	cfg := config.Load(configPath, cwd)
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, "", fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	searchRoot := cfg.ConfigDir
+	if cfg.ConfigFile == "" {
+		// Without a configuration file, search from the git work tree
+		if tree := changes.WorkTree(cwd); tree != "" {
+			searchRoot = tree
+		}
+	}
+
+	result, err := pipeline.Collect(searchRoot, cfg, pipeline.Options{MaxDepth: maxDepth, NoCache: noCache})
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to collect workspaces: %w", err)
+	}
+	return graph.Build(result.Workspaces), cfg, searchRoot, nil
+}
+
+// findWorkspace returns the workspace containing the path target, or named
+// by target with its relative path, name or an alias, filled in by
+// describer. An empty target stands for the current directory.
+func findWorkspace(workspaces []*workspace.Workspace, target, searchRoot string, describer *pipeline.Describer) (*workspace.Workspace, error) {
+	if target == "" {
+		target = "."
+	}
+	if absPath, err := filepath.Abs(target); err == nil {
+		if _, err := os.Stat(absPath); err == nil {
+			if ws := innermostWorkspace(workspaces, absPath); ws != nil {
+				return ws, nil
+			}
+		}
+	}
+
+	var matches []*workspace.Workspace
+	for _, ws := range workspaces {
+		_ = describer.Describe(ws, workspace.DetailMetadata)
+		if ws.RelativePath(searchRoot) == filepath.Clean(target) || ws.Name == target || slices.Contains(ws.Aliases, target) {
+			matches = append(matches, ws)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		if target == "." {
+			return nil, fmt.Errorf("not inside a workspace; name one")
+		}
+		return nil, fmt.Errorf("no workspace %q", target)
+	case 1:
+		return matches[0], nil
+	}
+	paths := make([]string, len(matches))
+	for i, ws := range matches {
+		paths[i] = ws.RelativePath(searchRoot)
+	}
+	return nil, fmt.Errorf("%q names several workspaces, give its path: %s", target, strings.Join(paths, ", "))
+}
+
+// innermostWorkspace returns the deepest workspace containing path, or nil
+func innermostWorkspace(workspaces []*workspace.Workspace, path string) *workspace.Workspace {
+	var found *workspace.Workspace
+	for _, ws := range workspaces {
+		if path == ws.Path || strings.HasPrefix(path, ws.Path+string(filepath.Separator)) {
+			if found == nil || len(ws.Path) > len(found.Path) {
+				found = ws
+			}
+		}
+	}
+	return found
+}
+
+// printCycles prints each dependency cycle on a line, or all of them as
+// JSON, and returns an error when there are any
+func printCycles(cycles [][]*workspace.Workspace, searchRoot string, format output.Format) error {
+	paths := make([][]string, len(cycles))
+	for i, cycle := range cycles {
+		paths[i] = make([]string, len(cycle))
+		for j, ws := range cycle {
+			paths[i][j] = ws.RelativePath(searchRoot)
+		}
+	}
+
+	if format == output.FormatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(paths); err != nil {
+			return err
+		}
+	} else {
+		for _, cycle := range paths {
+			fmt.Println(strings.Join(cycle, " <-> "))
+		}
+	}
+
+	if len(cycles) > 0 {
+		return fmt.Errorf("found %d dependency %s", len(cycles), plural(len(cycles), "cycle", "cycles"))
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupDependencies creates libs/log, libs/auth depending on it, and
+// services depending on them, and returns the root directory
+func setupDependencies(t *testing.T) string {
+	t.Helper()
+	tmpDir := setupWorkspaces(t, "libs/log", "libs/auth", "services/api", "services/worker")
+	requires := map[string]string{
+		"libs/auth":       "libs/log",
+		"services/api":    "libs/auth",
+		"services/worker": "libs/log",
+	}
+	for name, dep := range requires {
+		content := "module " + name + "\n\nrequire " + dep + " v0.0.0\n"
+		if err := os.WriteFile(filepath.Join(tmpDir, name, "go.mod"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tmpDir
+}
+
+// setupRepository creates a git repository without a configuration file,
+// holding the git repositories libs/log and libs/auth depending on it, and
+// changes to its docs directory. It returns the repository directory.
+func setupRepository(t *testing.T) string {
+	t.Helper()
+	tmpDir := setupWorkspaces(t)
+	if err := os.Remove(filepath.Join(tmpDir, ".panama.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"libs/log/go.mod":  "module libs/log\n",
+		"libs/auth/go.mod": "module libs/auth\n\nrequire libs/log v0.0.0\n",
+	}
+	for _, dir := range []string{".git", "libs/log/.git", "libs/auth/.git", "docs"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chdir(filepath.Join(tmpDir, "docs")); err != nil {
+		t.Fatal(err)
+	}
+	return tmpDir
+}
+
+func TestDeps(t *testing.T) {
+	tmpDir := setupDependencies(t)
+	path := func(names ...string) string {
+		var out string
+		for _, name := range names {
+			out += filepath.Join(tmpDir, name) + "\n"
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		dir     string
+		args    []string
+		opts    depsOptions
+		want    string
+		wantErr bool
+	}{
+		{name: "dependencies", args: []string{"services/api"}, want: path("libs/auth", "libs/log")},
+		{name: "direct dependencies", args: []string{"services/api"}, opts: depsOptions{depth: 1}, want: path("libs/auth")},
+		{name: "by name", args: []string{"auth"}, want: path("libs/log")},
+		{name: "dependents", args: []string{"libs/log"}, opts: depsOptions{dependents: true}, want: path("libs/auth", "services/worker", "services/api")},
+		{name: "current workspace", dir: "libs/log/internal", opts: depsOptions{dependents: true, depth: 1}, want: path("libs/auth", "services/worker")},
+		{name: "no dependencies", args: []string{"libs/log"}, want: ""},
+		{name: "unknown workspace", args: []string{"billing"}, wantErr: true},
+		{name: "no cycles", opts: depsOptions{cycles: true}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(tmpDir, tt.dir)
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chdir(dir); err != nil {
+				t.Fatal(err)
+			}
+			tt.opts.format = "path"
+
+			out, err := captureStdout(t, func() error { return runDeps(tt.args, &tt.opts) })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runDeps() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out != tt.want {
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeps_Cycles(t *testing.T) {
+	tmpDir := setupDependencies(t)
+	if err := os.WriteFile(filepath.Join(tmpDir, "libs", "log", "go.mod"), []byte("module libs/log\n\nrequire services/worker v0.0.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := captureStdout(t, func() error { return runDeps(nil, &depsOptions{format: "path", cycles: true}) })
+	if err == nil || !strings.Contains(err.Error(), "1 dependency cycle") {
+		t.Errorf("runDeps() error = %v, want one cycle", err)
+	}
+	if want := "libs/log <-> services/worker\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestDeps_WithoutConfig(t *testing.T) {
+	tmpDir := setupRepository(t)
+
+	out, err := captureStdout(t, func() error {
+		return runDeps([]string{"libs/log"}, &depsOptions{format: "path", dependents: true})
+	})
+	if err != nil {
+		t.Fatalf("runDeps() error = %v", err)
+	}
+	if want := filepath.Join(tmpDir, "libs", "auth") + "\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
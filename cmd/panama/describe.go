@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/yuya-takeyama/panama/internal/output"
+	"github.com/yuya-takeyama/panama/internal/pipeline"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// describeAll fills in the details of each workspace. Invalid workspace
+// files are left to list --verbose and doctor to report.
+func describeAll(workspaces []*workspace.Workspace, describer *pipeline.Describer, details workspace.Details) {
+	for _, ws := range workspaces {
+		_ = describer.Describe(ws, details)
+	}
+}
+
+// printWorkspaces prints workspaces in format. JSON output has every
+// detail, which is filled in first.
+func printWorkspaces(workspaces []*workspace.Workspace, format output.Format, describer *pipeline.Describer) error {
+	if format == output.FormatJSON {
+		describeAll(workspaces, describer, workspace.AllDetails)
+	}
+	return output.PrintWorkspaces(workspaces, format)
+}
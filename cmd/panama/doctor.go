@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/doctor"
+)
+
+type doctorOptions struct {
+	format   string
+	maxDepth int
+	config   string
+	slow     time.Duration
+	large    int
+	strict   bool
+}
+
+func newDoctorCommand() *cobra.Command {
+	opts := &doctorOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "doctor [path]",
+		Short: "Diagnose slow or incomplete workspace searches",
+		Long: `Check the configuration, walk the search root and report unreadable
+directories, broken symlinks, very large or slow subtrees, with suggestions
+for fixing each problem. Exits with an error when errors are found, or
+with --strict when warnings are found too.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctor(args, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.format, "format", "f", "text", "Output format (text|json)")
+	flags.IntVar(&opts.maxDepth, "max-depth", 0, "Maximum search depth (0 uses config default)")
+	flags.StringVar(&opts.config, "config", "", "Path to configuration file")
+	flags.DurationVar(&opts.slow, "slow", doctor.DefaultSlowDir, "Report subtrees taking longer than this to search")
+	flags.IntVar(&opts.large, "large", doctor.DefaultLargeSubtreeDirs, "Report subtrees with more directories than this")
+	flags.BoolVar(&opts.strict, "strict", false, "Exit with an error on warnings too")
+
+	return cmd
+}
+
+func runDoctor(args []string, opts *doctorOptions) error {
+	if opts.format != "text" && opts.format != "json" {
+		return fmt.Errorf("invalid format: %s", opts.format)
+	}
+
+	// Determine root directory
+	rootDir := "."
+	if len(args) > 0 {
+		rootDir = args[0]
+	}
+
+	// Convert to absolute path
+	absRoot, err := filepath.Abs(rootDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	// Load configuration; problems are reported as findings
+	cfg := config.Load(opts.config, absRoot)
+
+	// Use config directory as root if config was found
+	searchRoot := absRoot
+	if cfg.ConfigDir != "" {
+		searchRoot = cfg.ConfigDir
+	}
+
+	report, err := doctor.Run(searchRoot, cfg, doctor.Options{
+		MaxDepth:         opts.maxDepth,
+		SlowDir:          opts.slow,
+		LargeSubtreeDirs: opts.large,
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Root: %s\n\n", report.Root)
+		for _, f := range report.Findings {
+			fmt.Printf("[%s] %s: %s\n", f.Severity, f.Check, f.Message)
+			if f.Suggestion != "" {
+				fmt.Printf("    -> %s\n", f.Suggestion)
+			}
+		}
+		fmt.Println()
+	}
+
+	problems := report.Problems()
+	if opts.strict && problems > 0 {
+		return fmt.Errorf("%d %s found", problems, plural(problems, "problem", "problems"))
+	}
+	if n := report.Errors(); n > 0 {
+		return fmt.Errorf("%d %s found", n, plural(n, "error", "errors"))
+	}
+	if opts.format == "text" {
+		if problems > 0 {
+			fmt.Printf("%d %s found.\n", problems, plural(problems, "warning", "warnings"))
+		} else {
+			fmt.Println("No problems found.")
+		}
+	}
+	return nil
+}
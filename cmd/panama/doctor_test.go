@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDoctor_Warnings(t *testing.T) {
+	tmpDir := setupWorkspaces(t, "alpha")
+	if err := os.Remove(filepath.Join(tmpDir, ".panama.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A missing configuration file is only a warning
+	out, err := captureStdout(t, func() error { return runDoctor(nil, &doctorOptions{format: "text"}) })
+	if err != nil {
+		t.Fatalf("runDoctor() error = %v", err)
+	}
+	if !strings.Contains(out, "[warning] config: no configuration file found") || !strings.Contains(out, "1 warning found.") {
+		t.Errorf("output = %q, want the warning reported", out)
+	}
+
+	_, err = captureStdout(t, func() error { return runDoctor(nil, &doctorOptions{format: "text", strict: true}) })
+	if err == nil || err.Error() != "1 problem found" {
+		t.Errorf("runDoctor() with --strict error = %v, want 1 problem found", err)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/drill"
+	"github.com/yuya-takeyama/panama/internal/match"
+	"github.com/yuya-takeyama/panama/internal/ui/finder"
+	"github.com/yuya-takeyama/panama/internal/ui/fuzzyfinder"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// backKey returns from the drill-down finder to the workspace list
+const backKey = "esc"
+
+// errBack is returned when the user goes back to the workspace list
+var errBack = errors.New("back to the workspace list")
+
+// drillDown lets the user choose a file or directory inside ws and returns
+// its absolute path
+func drillDown(f finder.Finder, ws *workspace.Workspace, cfg *config.Config, kind drill.Kind) (string, error) {
+	entries, err := drill.List(ws.Path, cfg.IgnoreDirs, kind)
+	if err != nil {
+		return "", fmt.Errorf("failed to list %s: %w", ws.Path, err)
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("no entries found in %s", ws.Path)
+	}
+
+	items := make([]fuzzyfinder.Item, len(entries))
+	for i, entry := range entries {
+		label := entry.Path
+		if entry.IsDir && entry.Path != "." {
+			label += "/"
+		}
+		items[i] = fuzzyfinder.Item{
+			Label: label,
+			Path:  filepath.Join(ws.Path, filepath.FromSlash(entry.Path)),
+		}
+	}
+
+	matcher, err := match.New(cfg.Matcher)
+	if err != nil {
+		return "", err
+	}
+	opts := fuzzyfinder.Options{Prompt: ws.Name + " > ", Matcher: matcher}
+	if finder.SupportsKeys(f) {
+		// Without key support, leaving the finder aborts instead
+		opts.Header, opts.Keys = backKey+": back", []string{backKey}
+	}
+	result, err := f.Find(items, opts)
+	if err != nil {
+		return "", err
+	}
+	if result.Key == backKey {
+		return "", errBack
+	}
+	if len(result.Indices) == 0 {
+		return "", fuzzyfinder.ErrAbort
+	}
+	return items[result.Indices[0]].Path, nil
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/graph"
+	"github.com/yuya-takeyama/panama/internal/match"
+	"github.com/yuya-takeyama/panama/internal/pipeline"
+	"github.com/yuya-takeyama/panama/internal/runner"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+type execOptions struct {
+	maxDepth  int
+	noCache   bool
+	config    string
+	selection workspaceSelection
+	parallel  int
+	failFast  bool
+	json      bool
+	topo      bool
+}
+
+func newExecCommand() *cobra.Command {
+	opts := &execOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "exec [path] [flags] -- <command>...",
+		Short: "Run a command in each workspace",
+		Long: `Run a command in every workspace, or in those chosen with --type, --path
+and --where, several at a time. Each line of output is prefixed with the
+workspace name, and a summary of exit codes and durations follows. With
+--topo, each workspace waits for the selected workspaces it depends on, as
+panama deps reports them.
+
+A single command argument runs through the shell, so it may use pipes and
+&&; several arguments are executed directly. PANAMA_WORKSPACE holds the
+workspace path. Exits with an error when the command fails anywhere.`,
+		Example: `  panama exec --type go -- go test ./...
+  panama exec --where 'changed:origin/main' -- npm run lint
+  panama exec --path 'services/*' --fail-fast -- 'make build && make test'
+  panama exec --type node --topo -- npm run build`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			dash := cmd.ArgsLenAtDash()
+			if dash < 0 || dash == len(args) {
+				return fmt.Errorf("a command is required after --")
+			}
+			if dash > 1 {
+				return fmt.Errorf("at most one path is accepted before --")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dash := cmd.ArgsLenAtDash()
+			return runExec(args[:dash], args[dash:], opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&opts.maxDepth, "max-depth", 0, "Maximum search depth (0 uses config default)")
+	flags.BoolVar(&opts.noCache, "no-cache", false, "Disable caching")
+	flags.StringVar(&opts.config, "config", "", "Path to configuration file")
+	flags.StringSliceVarP(&opts.selection.types, "type", "t", nil, "Only workspaces of these package types, such as go or node")
+	flags.StringSliceVarP(&opts.selection.paths, "path", "p", nil, "Only workspaces whose relative path matches one of these globs")
+	flags.StringSliceVar(&opts.selection.owners, "owner", nil, "Only workspaces owned by one of these teams or people, such as @acme/web")
+	flags.StringSliceVar(&opts.selection.tags, "tag", nil, "Only workspaces with one of these tags")
+	flags.StringVarP(&opts.selection.where, "where", "w", "", "Only workspaces matching the query")
+	flags.IntVarP(&opts.parallel, "parallel", "j", runtime.NumCPU(), "Number of workspaces to run in at once")
+	flags.BoolVar(&opts.failFast, "fail-fast", false, "Stop at the first failure instead of running in every workspace")
+	flags.BoolVar(&opts.json, "json", false, "Print a JSON report on stdout; command output goes to stderr")
+	flags.BoolVar(&opts.topo, "topo", false, "Run in each workspace after the workspaces it depends on, skipping it when they fail")
+
+	return cmd
+}
+
+func runExec(args, command []string, opts *execOptions) error {
+	workspaces, _, _, _, err := collectSelection(args, opts.config, opts.maxDepth, opts.noCache, &opts.selection)
+	if err != nil {
+		return err
+	}
+
+	var g *graph.Graph
+	if opts.topo {
+		g = graph.Build(workspaces)
+		if workspaces, err = g.Sort(workspaces); err != nil {
+			return err
+		}
+	}
+
+	jobs := make([]runner.Job, len(workspaces))
+	names := make([]string, len(workspaces))
+	positions := make(map[*workspace.Workspace]int, len(workspaces))
+	for i, ws := range workspaces {
+		jobs[i] = runner.Job{Workspace: ws, Command: command}
+		names[i] = ws.Name
+		if g != nil {
+			for _, dep := range g.Dependencies(ws, 0) {
+				if j, ok := positions[dep]; ok {
+					jobs[i].Needs = append(jobs[i].Needs, j)
+				}
+			}
+		}
+		positions[ws] = i
+	}
+
+	results := runJobs(jobs, names, opts.parallel, opts.failFast, opts.json)
+	return runner.Summary(results)
+}
+
+// collectSelection collects the workspaces below the path in args and
+// returns those chosen by the selection flags, with the configuration, the
+// search root and the describer filling in their details
+func collectSelection(args []string, configPath string, maxDepth int, noCache bool, selection *workspaceSelection) ([]*workspace.Workspace, *config.Config, string, *pipeline.Describer, error) {
+	rootDir := "."
+	if len(args) > 0 {
+		rootDir = args[0]
+	}
+	absRoot, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, nil, "", nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	cfg := config.Load(configPath, absRoot)
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, "", nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	searchRoot := absRoot
+	if cfg.ConfigDir != "" {
+		searchRoot = cfg.ConfigDir
+	}
+
+	result, err := pipeline.Collect(searchRoot, cfg, pipeline.Options{MaxDepth: maxDepth, NoCache: noCache})
+	if err != nil {
+		return nil, nil, "", nil, fmt.Errorf("failed to collect workspaces: %w", err)
+	}
+	matcher, err := match.New(cfg.Matcher)
+	if err != nil {
+		return nil, nil, "", nil, err
+	}
+	describer := pipeline.NewDescriber(cfg)
+	workspaces, err := selection.filter(result.Workspaces, searchRoot, describer, matcher)
+	if err != nil {
+		return nil, nil, "", nil, err
+	}
+	if len(workspaces) == 0 {
+		return nil, nil, "", nil, fmt.Errorf("no workspaces match")
+	}
+	return workspaces, cfg, searchRoot, describer, nil
+}
+
+// runJobs runs the jobs, stopping them on Ctrl+C, and reports the results
+// as a table on stderr or as JSON on stdout
+func runJobs(jobs []runner.Job, names []string, parallel int, failFast, asJSON bool) []runner.Result {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var stdout io.Writer = os.Stdout
+	if asJSON {
+		stdout = os.Stderr
+	}
+	results := runner.Run(ctx, jobs, runner.Options{
+		Parallel: parallel,
+		FailFast: failFast,
+		Output:   runner.NewOutput(stdout, os.Stderr, names),
+	})
+
+	if asJSON {
+		printJSONReport(results)
+	} else {
+		printSummary(os.Stderr, results)
+	}
+	return results
+}
+
+// jsonResult is a result in the --json report
+type jsonResult struct {
+	runner.Result
+	DurationMS int64 `json:"duration_ms"`
+}
+
+func printJSONReport(results []runner.Result) {
+	report := make([]jsonResult, len(results))
+	for i, r := range results {
+		report[i] = jsonResult{Result: r, DurationMS: r.Duration.Milliseconds()}
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(report)
+}
+
+func printSummary(w io.Writer, results []runner.Result) {
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "WORKSPACE\tSTATUS\tEXIT\tDURATION")
+	for _, r := range results {
+		exit, duration := "-", "-"
+		if r.Status == runner.StatusOK || r.Status == runner.StatusFailed {
+			exit = fmt.Sprint(r.ExitCode)
+			duration = r.Duration.Round(time.Millisecond).String()
+		}
+		status := string(r.Status)
+		if r.Error != "" {
+			status += ": " + r.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Name, status, exit, duration)
+	}
+	tw.Flush()
+}
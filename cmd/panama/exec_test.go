@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	setupWorkspaces(t, "apps/web", "services/api", "services/worker")
+
+	tests := []struct {
+		name      string
+		selection workspaceSelection
+		command   string
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:      "path selection",
+			selection: workspaceSelection{paths: []string{"services/*"}},
+			command:   "basename $PWD",
+			want:      []string{"api    | api", "worker | worker"},
+		},
+		{
+			name:      "path and where selection",
+			selection: workspaceSelection{paths: []string{"services/*"}, where: "!worker"},
+			command:   "basename $PWD",
+			want:      []string{"api | api"},
+		},
+		{
+			name:      "failure",
+			selection: workspaceSelection{types: []string{"go"}},
+			command:   `test "$(basename $PWD)" != web`,
+			wantErr:   true,
+		},
+		{
+			name:      "no match",
+			selection: workspaceSelection{types: []string{"node"}},
+			command:   "true",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := captureStdout(t, func() error {
+				return runExec(nil, []string{tt.command}, &execOptions{noCache: true, parallel: 2, selection: tt.selection})
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runExec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var lines []string
+			if out != "" {
+				lines = strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+			}
+			slices.Sort(lines)
+			if !slices.Equal(lines, tt.want) {
+				t.Errorf("output = %q, want %q", lines, tt.want)
+			}
+		})
+	}
+}
+
+func TestExec_Topo(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	tmpDir := setupDependencies(t)
+	log := filepath.Join(tmpDir, "order.log")
+
+	// Dependencies finish last without --topo, as they sleep the longest
+	command := `case "$PANAMA_WORKSPACE" in */libs/log) sleep 0.3 ;; */libs/auth) sleep 0.1 ;; esac; basename "$PANAMA_WORKSPACE" >> ` + log
+	_, err := captureStdout(t, func() error {
+		return runExec(nil, []string{command}, &execOptions{noCache: true, parallel: 4, topo: true})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := strings.Fields(string(data))
+	before := func(a, b string) bool { return slices.Index(order, a) < slices.Index(order, b) }
+	if len(order) != 4 || !before("log", "auth") || !before("log", "worker") || !before("auth", "api") {
+		t.Errorf("order = %v, want dependencies first", order)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/pipeline"
+)
+
+type explainOptions struct {
+	format   string
+	maxDepth int
+	config   string
+}
+
+func newExplainCommand() *cobra.Command {
+	opts := &explainOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "explain <dir>",
+		Short: "Explain why a directory is or is not listed as a workspace",
+		Long: `Replay the workspace search for a single directory and print each decision:
+the configuration file used, the depth of every directory on the way, which
+ignore pattern or max_depth pruned it, which detection rule matched, and which
+enclosing workspace stopped the search.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExplain(args, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.format, "format", "f", "text", "Output format (text|json)")
+	flags.IntVar(&opts.maxDepth, "max-depth", 0, "Maximum search depth (0 uses config default)")
+	flags.StringVar(&opts.config, "config", "", "Path to configuration file")
+
+	return cmd
+}
+
+func runExplain(args []string, opts *explainOptions) error {
+	if opts.format != "text" && opts.format != "json" {
+		return fmt.Errorf("invalid format: %s", opts.format)
+	}
+
+	target, err := filepath.Abs(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	// Replay the search that list or select would run from here
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	// Load configuration
+	cfg := config.Load(opts.config, cwd)
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	searchRoot := cwd
+	if cfg.ConfigDir != "" {
+		searchRoot = cfg.ConfigDir
+	}
+
+	exp, err := pipeline.Explain(searchRoot, target, cfg, pipeline.Options{MaxDepth: opts.maxDepth})
+	if err != nil {
+		return err
+	}
+
+	if opts.format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(exp)
+	}
+
+	configFile := exp.ConfigFile
+	if configFile == "" {
+		configFile = "(none, using defaults)"
+	}
+	fmt.Printf("Target: %s\n", exp.Target)
+	fmt.Printf("Root:   %s\n", exp.Root)
+	fmt.Printf("Config: %s\n\n", configFile)
+
+	for _, step := range exp.Steps {
+		rel, err := filepath.Rel(exp.Root, step.Path)
+		if err != nil {
+			rel = step.Path
+		}
+		fmt.Printf("  [depth %d] %s: %s\n", step.Depth, rel, step.Message)
+	}
+
+	fmt.Printf("\nResult: %s\n", exp.Verdict)
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/graph"
+	"github.com/yuya-takeyama/panama/internal/pipeline"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+type graphOptions struct {
+	format   string
+	group    string
+	focus    string
+	depth    int
+	affected bool
+	since    string
+	maxDepth int
+	noCache  bool
+	config   string
+}
+
+func newGraphCommand() *cobra.Command {
+	opts := &graphOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Export the workspace dependency graph",
+		Long: `Print the workspaces and the dependencies between them, as panama deps
+reports them, in Graphviz DOT, Mermaid or JSON. Workspaces are identified by
+their path relative to the root and printed in path order, so the output
+can be committed and compared.
+
+--focus draws only a workspace with its dependencies and dependents, and
+--affected fills in the workspaces changed since affected.base, or --since,
+and those depending on them.`,
+		Example: `  panama graph | dot -Tsvg > workspaces.svg
+  panama graph -f mermaid --group dir > docs/workspaces.mmd
+  panama graph --focus libs/auth --depth 1 --since origin/main`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGraph(opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.format, "format", "f", "dot", "Output format (dot|mermaid|json)")
+	flags.StringVar(&opts.group, "group", "", "Cluster workspaces: none, dir or type (overrides config)")
+	flags.StringVar(&opts.focus, "focus", "", "Only draw this workspace and those it is connected to")
+	flags.IntVar(&opts.depth, "depth", 0, "With --focus, follow dependencies this many steps away (0 follows all)")
+	flags.BoolVar(&opts.affected, "affected", false, "Highlight the workspaces affected by git changes")
+	flags.StringVar(&opts.since, "since", "", "Git ref to compare against for --affected (overrides affected.base)")
+	flags.IntVar(&opts.maxDepth, "max-depth", 0, "Maximum search depth (0 uses config default)")
+	flags.BoolVar(&opts.noCache, "no-cache", false, "Disable caching")
+	flags.StringVar(&opts.config, "config", "", "Path to configuration file")
+
+	return cmd
+}
+
+func runGraph(opts *graphOptions) error {
+	format := graph.Format(opts.format)
+	if !slices.Contains(graph.Formats, format) {
+		return fmt.Errorf("invalid format: %s (must be one of: dot, mermaid, json)", opts.format)
+	}
+
+	g, cfg, searchRoot, err := buildGraph(opts.config, opts.maxDepth, opts.noCache)
+	if err != nil {
+		return err
+	}
+
+	group := cfg.Group
+	if opts.group != "" {
+		if !slices.Contains(config.Groupings, opts.group) {
+			return fmt.Errorf("invalid group: %s (must be one of: %s)", opts.group, strings.Join(config.Groupings, ", "))
+		}
+		group = opts.group
+	}
+
+	// Marks follow dependents outside the focus too
+	var marks map[*workspace.Workspace]graph.Mark
+	if opts.affected || opts.since != "" {
+		affected, err := affectedWorkspaces(searchRoot, opts.since, cfg, g.Workspaces())
+		if err != nil {
+			return err
+		}
+		marks = make(map[*workspace.Workspace]graph.Mark)
+		for _, ws := range affected.Workspaces {
+			for _, dependent := range g.Dependents(ws, 0) {
+				marks[dependent] = graph.MarkImpacted
+			}
+		}
+		for _, ws := range affected.Workspaces {
+			marks[ws] = graph.MarkAffected
+		}
+	}
+
+	if opts.focus != "" {
+		ws, err := findWorkspace(g.Workspaces(), opts.focus, searchRoot, pipeline.NewDescriber(cfg))
+		if err != nil {
+			return err
+		}
+		nodes := append([]*workspace.Workspace{ws}, g.Dependencies(ws, opts.depth)...)
+		g = g.Subgraph(append(nodes, g.Dependents(ws, opts.depth)...))
+	}
+
+	return graph.Export(os.Stdout, g, format, graph.ExportOptions{
+		Root:  searchRoot,
+		Group: group,
+		Marks: marks,
+	})
+}
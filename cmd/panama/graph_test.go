@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func TestGraph(t *testing.T) {
+	setupDependencies(t)
+
+	tests := []struct {
+		name    string
+		opts    graphOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "focus",
+			opts: graphOptions{format: "mermaid", focus: "libs/auth", depth: 1},
+			want: `flowchart LR
+  ws_libs_auth["libs/auth"]
+  ws_libs_log["libs/log"]
+  ws_services_api["services/api"]
+  ws_libs_auth --> ws_libs_log
+  ws_services_api --> ws_libs_auth
+`,
+		},
+		{
+			name: "grouped by type",
+			opts: graphOptions{format: "dot", group: "type", focus: "services/worker"},
+			want: `digraph workspaces {
+  rankdir=LR;
+  node [shape=box];
+
+  subgraph "cluster_go" {
+    label="go";
+    "libs/log" [label="libs/log"];
+    "services/worker" [label="services/worker"];
+  }
+
+  "services/worker" -> "libs/log";
+}
+`,
+		},
+		{name: "invalid format", opts: graphOptions{format: "svg"}, wantErr: true},
+		{name: "invalid group", opts: graphOptions{format: "dot", group: "team"}, wantErr: true},
+		{name: "unknown focus", opts: graphOptions{format: "dot", focus: "billing"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := captureStdout(t, func() error { return runGraph(&tt.opts) })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runGraph() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out != tt.want {
+				t.Errorf("output =\n%s\nwant\n%s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraph_WithoutConfig(t *testing.T) {
+	setupRepository(t)
+
+	out, err := captureStdout(t, func() error { return runGraph(&graphOptions{format: "mermaid", focus: "libs/auth"}) })
+	if err != nil {
+		t.Fatalf("runGraph() error = %v", err)
+	}
+	want := `flowchart LR
+  ws_libs_auth["libs/auth"]
+  ws_libs_log["libs/log"]
+  ws_libs_auth --> ws_libs_log
+`
+	if out != want {
+		t.Errorf("output =\n%s\nwant\n%s", out, want)
+	}
+}
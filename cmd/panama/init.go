@@ -1,19 +1,53 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	_ "embed"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
 
 	"github.com/spf13/cobra"
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/inspect"
+	"golang.org/x/term"
 )
 
-//go:embed templates/default-config.yaml
-var defaultConfigTemplate string
+//go:embed templates/config.yaml.tmpl
+var configTemplateText string
+
+var configTemplate = template.Must(template.New("config").Parse(configTemplateText))
+
+// Patterns and ignored directories used when the scan finds nothing
+var (
+	defaultPatterns    = []string{"package.json", "go.mod", "pyproject.toml"}
+	defaultIgnoredDirs = []string{
+		"node_modules", ".git", "vendor", "target", "dist",
+		"build", ".next", ".nuxt", ".cache", "__pycache__",
+	}
+)
 
 type initOptions struct {
-	force bool
+	force    bool
+	yes      bool
+	dryRun   bool
+	noSchema bool
+}
+
+type configEntry struct {
+	Value   string
+	Comment string
+}
+
+type configTemplateData struct {
+	SchemaPath  string
+	MaxDepth    int
+	Patterns    []configEntry
+	IgnoredDirs []configEntry
 }
 
 func newInitCommand() *cobra.Command {
@@ -22,7 +56,9 @@ func newInitCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Initialize a panama configuration file",
-		Long:  `Create a .panama.yaml configuration file in the current directory with default settings.`,
+		Long: `Create a .panama.yaml configuration file in the current directory.
+The directory tree is scanned first to propose workspace patterns and
+ignored directories based on the files that actually occur.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runInit(opts)
 		},
@@ -30,6 +66,9 @@ func newInitCommand() *cobra.Command {
 
 	flags := cmd.Flags()
 	flags.BoolVar(&opts.force, "force", false, "Overwrite existing configuration file")
+	flags.BoolVarP(&opts.yes, "yes", "y", false, "Write the proposed configuration without asking")
+	flags.BoolVar(&opts.dryRun, "dry-run", false, "Print the proposed configuration without writing it")
+	flags.BoolVar(&opts.noSchema, "no-schema", false, "Do not write the JSON Schema file for editor integration")
 
 	return cmd
 }
@@ -39,16 +78,160 @@ func runInit(opts *initOptions) error {
 	filename := ".panama.yaml"
 
 	// Check if file already exists
-	if _, err := os.Stat(filename); err == nil && !opts.force {
-		return fmt.Errorf("configuration file %s already exists (use --force to overwrite)", filename)
+	if !opts.dryRun {
+		if _, err := os.Stat(filename); err == nil && !opts.force {
+			return fmt.Errorf("configuration file %s already exists (use --force to overwrite)", filename)
+		}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	report, err := inspect.Inspect(cwd, inspect.Options{})
+	if err != nil {
+		return fmt.Errorf("failed to inspect directory: %w", err)
+	}
+
+	// A dry run writes no schema, so the header would point nowhere
+	schemaPath := ""
+	if !opts.noSchema && !opts.dryRun {
+		schemaPath = "./" + config.SchemaFileName
+	}
+
+	content, err := renderConfig(report, schemaPath)
+	if err != nil {
+		return fmt.Errorf("failed to render configuration: %w", err)
+	}
+
+	if opts.dryRun {
+		fmt.Print(content)
+		return nil
+	}
+
+	if !opts.yes {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return fmt.Errorf("refusing to write %s without confirmation (use --yes or --dry-run)", filename)
+		}
+
+		printReport(report)
+		fmt.Fprintf(os.Stderr, "\n%s\n", content)
+		ok, err := confirm(fmt.Sprintf("Write %s?", filename))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("cancelled")
+		}
 	}
 
 	// Write configuration file from template
-	if err := os.WriteFile(filename, []byte(defaultConfigTemplate), 0644); err != nil {
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write configuration file: %w", err)
 	}
 
 	absPath, _ := filepath.Abs(filename)
 	fmt.Printf("Configuration file created: %s\n", absPath)
+
+	// Write the schema referenced by the yaml-language-server header
+	if !opts.noSchema {
+		data, err := config.SchemaJSON()
+		if err != nil {
+			return fmt.Errorf("failed to generate schema: %w", err)
+		}
+		if err := os.WriteFile(config.SchemaFileName, data, 0644); err != nil {
+			return fmt.Errorf("failed to write schema file: %w", err)
+		}
+		absSchema, _ := filepath.Abs(config.SchemaFileName)
+		fmt.Printf("Schema file created: %s\n", absSchema)
+	}
+
 	return nil
 }
+
+// renderConfig builds the configuration file proposed for report
+func renderConfig(report *inspect.Report, schemaPath string) (string, error) {
+	data := configTemplateData{
+		SchemaPath: schemaPath,
+		MaxDepth:   max(config.DefaultConfig().MaxDepth, report.MaxDepth),
+	}
+
+	for _, m := range report.Markers {
+		data.Patterns = append(data.Patterns, configEntry{
+			Value:   yamlString(m.Pattern),
+			Comment: fmt.Sprintf("%s, found in %d %s", m.Type, m.Count, plural(m.Count, "directory", "directories")),
+		})
+	}
+	if len(data.Patterns) == 0 {
+		for _, p := range defaultPatterns {
+			data.Patterns = append(data.Patterns, configEntry{Value: yamlString(p)})
+		}
+	}
+
+	for _, d := range report.IgnoredDirs {
+		comment := fmt.Sprintf("%d %s, %d entries", d.Count, plural(d.Count, "directory", "directories"), d.Entries)
+		if !d.Known {
+			comment = "large directory, " + comment
+		}
+		data.IgnoredDirs = append(data.IgnoredDirs, configEntry{Value: yamlString(d.Name), Comment: comment})
+	}
+	if len(data.IgnoredDirs) == 0 {
+		for _, d := range defaultIgnoredDirs {
+			data.IgnoredDirs = append(data.IgnoredDirs, configEntry{Value: yamlString(d)})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := configTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func printReport(report *inspect.Report) {
+	if len(report.Markers) == 0 {
+		fmt.Fprintln(os.Stderr, "No known workspace markers found; proposing default patterns.")
+	} else {
+		fmt.Fprintln(os.Stderr, "Workspace markers found:")
+		for _, m := range report.Markers {
+			fmt.Fprintf(os.Stderr, "  %-18s %5d\n", m.Pattern, m.Count)
+		}
+	}
+
+	if len(report.IgnoredDirs) > 0 {
+		fmt.Fprintln(os.Stderr, "Directories to ignore:")
+		for _, d := range report.IgnoredDirs {
+			fmt.Fprintf(os.Stderr, "  %-18s %5d entries\n", d.Name, d.Entries)
+		}
+	}
+}
+
+func confirm(prompt string) (bool, error) {
+	fmt.Fprintf(os.Stderr, "%s [Y/n] ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false, fmt.Errorf("failed to read answer: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// yamlString quotes s when it would not be read back as a plain YAML string
+func yamlString(s string) string {
+	if s == "" || strings.ContainsAny(s, "*?:#{}[]&!|>'\"%@`,") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return singular
+	}
+	return pluralForm
+}
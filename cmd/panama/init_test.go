@@ -0,0 +1,127 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/yuya-takeyama/panama/internal/config"
+)
+
+func TestInitCommand(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         *initOptions
+		existing     bool
+		wantErr      bool
+		wantFile     bool
+		wantPatterns []string
+	}{
+		{
+			name:         "dry run prints proposal",
+			opts:         &initOptions{dryRun: true},
+			wantFile:     false,
+			wantPatterns: []string{"go.mod", "Chart.yaml"},
+		},
+		{
+			name:         "yes writes proposal",
+			opts:         &initOptions{yes: true},
+			wantFile:     true,
+			wantPatterns: []string{"go.mod", "Chart.yaml"},
+		},
+		{
+			name:     "existing file without force",
+			opts:     &initOptions{yes: true},
+			existing: true,
+			wantErr:  true,
+		},
+		{
+			name:     "non-interactive without yes",
+			opts:     &initOptions{},
+			wantErr:  true,
+			wantFile: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			originalDir, err := os.Getwd()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Chdir(originalDir)
+
+			tmpDir := t.TempDir()
+			for _, name := range []string{"svc/a/go.mod", "svc/b/go.mod", "charts/c/Chart.yaml"} {
+				path := filepath.Join(tmpDir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(""), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.existing {
+				if err := os.WriteFile(filepath.Join(tmpDir, ".panama.yaml"), []byte("max_depth: 5\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.Chdir(tmpDir); err != nil {
+				t.Fatal(err)
+			}
+
+			// Capture stdout
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			err = runInit(tt.opts)
+
+			w.Close()
+			os.Stdout = oldStdout
+			out, _ := io.ReadAll(r)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runInit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			content := string(out)
+			if tt.wantFile {
+				data, err := os.ReadFile(filepath.Join(tmpDir, ".panama.yaml"))
+				if err != nil {
+					t.Fatalf("expected configuration file to be written: %v", err)
+				}
+				content = string(data)
+				if _, err := os.Stat(filepath.Join(tmpDir, config.SchemaFileName)); err != nil {
+					t.Errorf("expected schema file to be written: %v", err)
+				}
+			} else if _, err := os.Stat(filepath.Join(tmpDir, ".panama.yaml")); err == nil {
+				t.Errorf("expected no configuration file to be written")
+			}
+
+			cfg := config.DefaultConfig()
+			if err := yaml.Unmarshal([]byte(content), cfg); err != nil {
+				t.Fatalf("proposed configuration is not valid YAML: %v\n%s", err, content)
+			}
+			if !slices.Equal(cfg.Patterns, tt.wantPatterns) {
+				t.Errorf("Patterns = %v, want %v", cfg.Patterns, tt.wantPatterns)
+			}
+			if hasHeader := strings.HasPrefix(content, "# yaml-language-server: $schema=./"+config.SchemaFileName); hasHeader != tt.wantFile {
+				t.Errorf("schema header = %v, want it only with the schema file written, got:\n%s", hasHeader, content)
+			}
+			if _, err := os.Stat(filepath.Join(tmpDir, config.SchemaFileName)); !tt.wantFile && err == nil {
+				t.Errorf("expected no schema file to be written")
+			}
+			if !strings.Contains(content, "found in 2 directories") {
+				t.Errorf("expected marker counts in proposal, got:\n%s", content)
+			}
+		})
+	}
+}
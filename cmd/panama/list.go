@@ -2,12 +2,18 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/match"
+	"github.com/yuya-takeyama/panama/internal/order"
 	"github.com/yuya-takeyama/panama/internal/output"
 	"github.com/yuya-takeyama/panama/internal/pipeline"
+	"github.com/yuya-takeyama/panama/internal/query"
+	"github.com/yuya-takeyama/panama/internal/workspace"
 )
 
 type listOptions struct {
@@ -15,6 +21,12 @@ type listOptions struct {
 	maxDepth int
 	noCache  bool
 	config   string
+	verbose  bool
+	where    string
+	owners   []string
+	tags     []string
+	sort     string
+	reverse  bool
 }
 
 func newListCommand() *cobra.Command {
@@ -24,7 +36,11 @@ func newListCommand() *cobra.Command {
 		Use:   "list [path]",
 		Short: "List all available workspaces",
 		Long: `List all workspaces found in the specified directory or current directory.
-Output can be formatted as paths or JSON.`,
+Output can be formatted as paths or JSON.
+
+--where filters the list with the query language of the interactive finder,
+e.g. --where 'type:go depth:<3 !legacy'. --owner keeps the workspaces owned
+by one of the given owners, and --tag those carrying one of the given tags.`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runList(args, opts)
@@ -32,10 +48,16 @@ Output can be formatted as paths or JSON.`,
 	}
 
 	flags := cmd.Flags()
-	flags.StringVarP(&opts.format, "format", "f", "path", "Output format (path|json)")
+	flags.StringVarP(&opts.format, "format", "f", "path", "Output format (path|json|nul)")
 	flags.IntVar(&opts.maxDepth, "max-depth", 0, "Maximum search depth (0 uses config default)")
 	flags.BoolVar(&opts.noCache, "no-cache", false, "Disable caching")
 	flags.StringVar(&opts.config, "config", "", "Path to configuration file")
+	flags.BoolVarP(&opts.verbose, "verbose", "v", false, "Report unreadable directories and other walk errors")
+	flags.StringVar(&opts.where, "where", "", "Only list workspaces matching the query")
+	flags.StringSliceVar(&opts.owners, "owner", nil, "Only list workspaces owned by one of these teams or people, such as @acme/web")
+	flags.StringSliceVar(&opts.tags, "tag", nil, "Only list workspaces with one of these tags")
+	flags.StringVar(&opts.sort, "sort", "", "Sort order: path, name, depth, modified, commit or frecency (overrides config)")
+	flags.BoolVar(&opts.reverse, "reverse", false, "Reverse the sort order")
 
 	return cmd
 }
@@ -65,6 +87,15 @@ func runList(args []string, opts *listOptions) error {
 		return err
 	}
 
+	if _, err := query.Parse(opts.where); err != nil {
+		return fmt.Errorf("invalid --where query: %w", err)
+	}
+
+	sortOpts, err := sortOptions(cfg, opts.sort, opts.reverse)
+	if err != nil {
+		return err
+	}
+
 	// Collect workspaces
 	pipelineOpts := pipeline.Options{
 		MaxDepth: opts.maxDepth,
@@ -77,15 +108,46 @@ func runList(args []string, opts *listOptions) error {
 		searchRoot = cfg.ConfigDir
 	}
 
-	workspaces, err := pipeline.CollectWorkspaces(searchRoot, cfg, pipelineOpts)
+	result, err := pipeline.Collect(searchRoot, cfg, pipelineOpts)
 	if err != nil {
 		return fmt.Errorf("failed to collect workspaces: %w", err)
 	}
+	describer := pipeline.NewDescriber(cfg)
+	if opts.verbose {
+		result.Describe(describer, workspace.DetailMetadata)
+		printWalkReport(result, searchRoot)
+	}
+	workspaces := result.Workspaces
 
 	if len(workspaces) == 0 {
 		return fmt.Errorf("no workspaces found")
 	}
 
+	selection := workspaceSelection{owners: opts.owners, tags: opts.tags, where: opts.where}
+	if !selection.empty() {
+		matcher, err := match.New(cfg.Matcher)
+		if err != nil {
+			return err
+		}
+		if workspaces, err = selection.filter(workspaces, searchRoot, describer, matcher); err != nil {
+			return err
+		}
+		if len(workspaces) == 0 {
+			return fmt.Errorf("no workspaces match the filters")
+		}
+	}
+
+	order.Sort(workspaces, sortOpts)
+
 	// Output workspaces
-	return output.PrintWorkspaces(workspaces, format)
+	return printWorkspaces(workspaces, format, describer)
+}
+
+// printWalkReport writes walk errors and a search summary to stderr
+func printWalkReport(result *pipeline.Result, root string) {
+	for _, walkErr := range result.Errors {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", walkErr)
+	}
+	fmt.Fprintf(os.Stderr, "searched %d directories under %s in %s: %d workspaces, %d errors\n",
+		result.Visited, root, result.Elapsed.Round(time.Millisecond), len(result.Workspaces), len(result.Errors))
 }
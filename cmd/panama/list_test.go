@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListWhere(t *testing.T) {
+	tmpDir := setupWorkspaces(t, "apps/web", "services/api", "services/legacy")
+
+	tests := []struct {
+		name    string
+		where   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "structured and text terms",
+			where: "type:go path:services/* !legacy",
+			want:  filepath.Join(tmpDir, "services", "api") + "\n",
+		},
+		{
+			name:  "fuzzy text",
+			where: "aw",
+			want:  filepath.Join(tmpDir, "apps", "web") + "\n",
+		},
+		{
+			name:    "no match",
+			where:   "type:node",
+			wantErr: true,
+		},
+		{
+			name:    "invalid query",
+			where:   "color:red",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := captureStdout(t, func() error {
+				return runList(nil, &listOptions{format: "path", noCache: true, where: tt.where})
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out != tt.want {
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestListSort(t *testing.T) {
+	tmpDir := setupWorkspaces(t, "alpha", "beta/nested", "gamma")
+
+	list := func(opts *listOptions) string {
+		t.Helper()
+		opts.format = "path"
+		out, err := captureStdout(t, func() error { return runList(nil, opts) })
+		if err != nil {
+			t.Fatalf("runList() error = %v", err)
+		}
+		return out
+	}
+	paths := func(names ...string) string {
+		var out string
+		for _, name := range names {
+			out += filepath.Join(tmpDir, name) + "\n"
+		}
+		return out
+	}
+
+	if got, want := list(&listOptions{sort: "depth", reverse: true}), paths("beta/nested", "gamma", "alpha"); got != want {
+		t.Errorf("--sort depth --reverse = %q, want %q", got, want)
+	}
+
+	// Selections are recorded for the frecency order
+	if _, err := captureStdout(t, func() error {
+		return runSelect(nil, &selectOptions{format: "path", query: "gamma"})
+	}); err != nil {
+		t.Fatalf("runSelect() error = %v", err)
+	}
+	if got, want := list(&listOptions{sort: "frecency"}), paths("gamma", "alpha", "beta/nested"); got != want {
+		t.Errorf("--sort frecency = %q, want %q", got, want)
+	}
+
+	if _, err := captureStdout(t, func() error {
+		return runList(nil, &listOptions{format: "path", sort: "size"})
+	}); err == nil {
+		t.Error("expected an error for an unknown sort order")
+	}
+}
+
+func TestListTag(t *testing.T) {
+	tmpDir := setupWorkspaces(t, "apps/web", "services/api", "services/worker")
+	config := "patterns:\n  - go.mod\nworkspaces:\n  \"services/*\":\n    tags: [backend]\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".panama.yaml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "apps", "web", ".panama-workspace.yaml"), []byte("tags: [frontend]\naliases: [storefront]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return runList(nil, &listOptions{format: "path", noCache: true, tags: []string{"backend"}})
+	})
+	if err != nil {
+		t.Fatalf("runList() error = %v", err)
+	}
+	if want := filepath.Join(tmpDir, "services", "api") + "\n" + filepath.Join(tmpDir, "services", "worker") + "\n"; out != want {
+		t.Errorf("--tag backend = %q, want %q", out, want)
+	}
+
+	// The finder matches aliases besides paths
+	out, err = captureStdout(t, func() error {
+		return runSelect(nil, &selectOptions{format: "path", query: "storefront"})
+	})
+	if err != nil {
+		t.Fatalf("runSelect() error = %v", err)
+	}
+	if want := filepath.Join(tmpDir, "apps", "web") + "\n"; out != want {
+		t.Errorf("select -q storefront = %q, want %q", out, want)
+	}
+}
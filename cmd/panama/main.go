@@ -34,8 +34,20 @@ It automatically detects Git repositories and project directories with package f
 	rootCmd.AddCommand(
 		newSelectCommand(),
 		newListCommand(),
+		newExecCommand(),
+		newRunCommand(),
+		newAffectedCommand(),
+		newWhichCommand(),
+		newUpCommand(),
+		newDepsCommand(),
+		newDependentsCommand(),
+		newGraphCommand(),
+		newOwnersCommand(),
 		newInitCommand(),
 		newRootCommand(),
+		newExplainCommand(),
+		newDoctorCommand(),
+		newConfigCommand(),
 		newVersionCommand(),
 	)
 
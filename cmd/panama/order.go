@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/history"
+	"github.com/yuya-takeyama/panama/internal/order"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// sortOptions returns the order given by --sort, or by the sort key when the
+// flag is empty
+func sortOptions(cfg *config.Config, sort string, reverse bool) (order.Options, error) {
+	if sort == "" {
+		sort = cfg.Sort
+	}
+	opts := order.Options{Order: order.Path, Reverse: reverse, IgnoreDirs: cfg.IgnoreDirs}
+	if sort != "" {
+		o, err := order.Parse(sort)
+		if err != nil {
+			return opts, err
+		}
+		opts.Order = o
+	}
+
+	if opts.Order == order.Frecency {
+		h, err := history.Load(history.DefaultPath())
+		if err != nil {
+			return opts, err
+		}
+		opts.History = h
+	}
+	return opts, nil
+}
+
+// recordSelection adds the chosen workspaces to the history used by the
+// frecency order, whatever the current order, so that the history is ready
+// when frecency is chosen. Failures are reported but do not fail the
+// selection.
+func recordSelection(selected []*workspace.Workspace, silent bool) {
+	err := func() error {
+		h, err := history.Load(history.DefaultPath())
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, ws := range selected {
+			h.Record(ws.Path, now)
+		}
+		return h.Save()
+	}()
+	if err != nil && !silent {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
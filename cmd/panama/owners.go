@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/yuya-takeyama/panama/internal/codeowners"
+	"github.com/yuya-takeyama/panama/internal/output"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+type ownersOptions struct {
+	maxDepth int
+	noCache  bool
+	config   string
+	unowned  bool
+	json     bool
+}
+
+func newOwnersCommand() *cobra.Command {
+	opts := &ownersOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "owners [path]",
+		Short: "Report the owners of each workspace",
+		Long: `Print the owners CODEOWNERS assigns to each workspace. The CODEOWNERS file is
+read from .github/, the repository root or docs/, in that order, and the last
+matching rule wins, as on GitHub. Owners set in .panama-workspace.yaml or the
+workspaces section of the configuration are added.
+
+With --unowned, only the workspaces without owners are listed, and the
+command fails when there are any, so that CI can require an owner for every
+workspace.`,
+		Example: `  panama owners
+  panama owners --unowned
+  panama list --owner @acme/payments`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runOwners(args, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&opts.maxDepth, "max-depth", 0, "Maximum search depth (0 uses config default)")
+	flags.BoolVar(&opts.noCache, "no-cache", false, "Disable caching")
+	flags.StringVar(&opts.config, "config", "", "Path to configuration file")
+	flags.BoolVar(&opts.unowned, "unowned", false, "Only list workspaces without owners, failing when there are any")
+	flags.BoolVar(&opts.json, "json", false, "Print the workspaces as JSON")
+
+	return cmd
+}
+
+func runOwners(args []string, opts *ownersOptions) error {
+	workspaces, _, searchRoot, describer, err := collectSelection(args, opts.config, opts.maxDepth, opts.noCache, &workspaceSelection{})
+	if err != nil {
+		return err
+	}
+
+	describeAll(workspaces, describer, workspace.DetailOwners)
+
+	var unowned []*workspace.Workspace
+	for _, ws := range workspaces {
+		if len(ws.Owners) == 0 {
+			unowned = append(unowned, ws)
+		}
+	}
+	if len(unowned) == len(workspaces) && codeowners.NewIndex().File(searchRoot) == nil {
+		return fmt.Errorf("no CODEOWNERS file found in .github/, the repository root or docs/")
+	}
+
+	listed := workspaces
+	if opts.unowned {
+		listed = unowned
+	}
+
+	if opts.json {
+		if err := printWorkspaces(listed, output.FormatJSON, describer); err != nil {
+			return err
+		}
+	} else if opts.unowned {
+		for _, ws := range listed {
+			fmt.Println(ws.RelativePath(searchRoot))
+		}
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "WORKSPACE\tOWNERS")
+		for _, ws := range listed {
+			owners := strings.Join(ws.Owners, " ")
+			if owners == "" {
+				owners = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", ws.RelativePath(searchRoot), owners)
+		}
+		tw.Flush()
+	}
+
+	if opts.unowned && len(unowned) > 0 {
+		return fmt.Errorf("%d of %d workspaces have no owner", len(unowned), len(workspaces))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOwners(t *testing.T) {
+	tmpDir := setupWorkspaces(t, "apps/web", "services/api", "services/billing")
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	codeowners := "/apps/ @acme/web\n/services/billing/ @acme/payments @alice\n"
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".github"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".github", "CODEOWNERS"), []byte(codeowners), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("table", func(t *testing.T) {
+		out, err := captureStdout(t, func() error { return runOwners(nil, &ownersOptions{noCache: true}) })
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The repository root is a workspace too
+		want := "WORKSPACE         OWNERS\n" +
+			".                 -\n" +
+			"apps/web          @acme/web\n" +
+			"services/api      -\n" +
+			"services/billing  @acme/payments @alice\n"
+		if out != want {
+			t.Errorf("output =\n%s\nwant\n%s", out, want)
+		}
+	})
+
+	t.Run("unowned", func(t *testing.T) {
+		out, err := captureStdout(t, func() error { return runOwners(nil, &ownersOptions{noCache: true, unowned: true}) })
+		if err == nil || !strings.Contains(err.Error(), "2 of 4") {
+			t.Errorf("runOwners() error = %v, want 2 of 4 unowned", err)
+		}
+		if want := ".\nservices/api\n"; out != want {
+			t.Errorf("output = %q, want %q", out, want)
+		}
+	})
+
+	t.Run("owner filter", func(t *testing.T) {
+		out, err := captureStdout(t, func() error {
+			return runList(nil, &listOptions{format: "path", noCache: true, owners: []string{"@acme/pay*"}})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join(tmpDir, "services", "billing") + "\n"; out != want {
+			t.Errorf("output = %q, want %q", out, want)
+		}
+	})
+
+	t.Run("no CODEOWNERS", func(t *testing.T) {
+		if err := os.Remove(filepath.Join(tmpDir, ".github", "CODEOWNERS")); err != nil {
+			t.Fatal(err)
+		}
+		_, err := captureStdout(t, func() error { return runOwners(nil, &ownersOptions{noCache: true}) })
+		if err == nil || !strings.Contains(err.Error(), "no CODEOWNERS file") {
+			t.Errorf("runOwners() error = %v, want a missing CODEOWNERS error", err)
+		}
+	})
+}
@@ -6,12 +6,16 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"github.com/yuya-takeyama/panama/internal/config"
 	"github.com/yuya-takeyama/panama/internal/output"
+	"github.com/yuya-takeyama/panama/internal/rootdir"
 )
 
 type rootOptions struct {
-	format string
-	config string
+	format    string
+	config    string
+	markers   []string
+	outermost bool
 }
 
 func newRootCommand() *cobra.Command {
@@ -20,8 +24,14 @@ func newRootCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "root",
 		Short: "Print the root directory containing panama config or .git",
-		Long: `Print the path to the first parent directory containing a panama configuration file or .git directory.
-This is useful for navigating to the monorepo or project root directory.`,
+		Long: `Print the path to the first parent directory containing a panama configuration file
+or one of the root markers, .git by default. This is useful for navigating to the
+monorepo or project root directory.
+
+root.markers adds markers such as go.work or pnpm-workspace.yaml, and
+root.outermost takes the outermost marked directory instead of the nearest.
+The search never climbs into the directories listed in root.ceilings,
+PANAMA_CEILING_DIRECTORIES or GIT_CEILING_DIRECTORIES.`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runRoot(opts)
@@ -31,6 +41,8 @@ This is useful for navigating to the monorepo or project root directory.`,
 	flags := cmd.Flags()
 	flags.StringVarP(&opts.format, "format", "f", "path", "Output format (path|cd|json)")
 	flags.StringVar(&opts.config, "config", "", "Path to configuration file")
+	flags.StringSliceVar(&opts.markers, "marker", nil, "Additional files or directories marking a root")
+	flags.BoolVar(&opts.outermost, "outermost", false, "Print the outermost root instead of the nearest (overrides config)")
 
 	return cmd
 }
@@ -52,37 +64,25 @@ func runRoot(opts *rootOptions) error {
 		return output.Print(absDir, format)
 	}
 
-	// Search for config file or .git directory upward from current directory
+	// Search for config file or a marker upward from current directory
 	currentDir, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("failed to get current directory: %w", err)
 	}
 
-	dir := currentDir
-	for {
-		// First, check for panama config files
-		for _, name := range []string{".panama.yaml", ".panama.yml"} {
-			path := filepath.Join(dir, name)
-			if _, err := os.Stat(path); err == nil {
-				// Found config file, return this directory
-				return output.Print(dir, format)
-			}
-		}
-
-		// Also check for .git directory as fallback
-		gitPath := filepath.Join(dir, ".git")
-		if stat, err := os.Stat(gitPath); err == nil && stat.IsDir() {
-			// Found .git directory, return this directory
-			return output.Print(dir, format)
-		}
-
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			break
-		}
-		dir = parent
+	cfg := config.Load("", currentDir)
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	// No config or .git found
-	return fmt.Errorf("no root workspace found in any parent directory")
+	dir := rootdir.Find(currentDir, rootdir.Options{
+		Markers:   append(cfg.Root.Markers, opts.markers...),
+		Match:     func(dir string) bool { return config.FindInDir(dir) != "" },
+		Outermost: cfg.Root.Outermost || opts.outermost,
+		Ceilings:  append(rootdir.EnvCeilings(), cfg.Root.CeilingDirs()...),
+	})
+	if dir == "" {
+		return fmt.Errorf("no root workspace found in any parent directory")
+	}
+	return output.Print(dir, format)
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -154,3 +155,66 @@ func TestRootCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestRootCommand_Markers(t *testing.T) {
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(originalDir)
+
+	// repo/.git, repo/go/go.work with its config, repo/go/svc/.git
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := filepath.Join(root, "repo")
+	goDir := filepath.Join(repo, "go")
+	svc := filepath.Join(goDir, "svc")
+	for _, dir := range []string{filepath.Join(repo, ".git"), filepath.Join(svc, ".git"), filepath.Join(svc, "cmd")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(goDir, "go.work"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".panama.yaml"), []byte("root:\n  markers: [go.work]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		dir      string
+		opts     *rootOptions
+		ceilings string
+		want     string
+		wantErr  bool
+	}{
+		{name: "nearest marker", dir: filepath.Join(svc, "cmd"), opts: &rootOptions{}, want: svc},
+		{name: "configured marker", dir: goDir, opts: &rootOptions{}, want: goDir},
+		{name: "marker flag", dir: root, opts: &rootOptions{markers: []string{"repo"}}, want: root},
+		{name: "outermost", dir: filepath.Join(svc, "cmd"), opts: &rootOptions{outermost: true}, want: repo},
+		{name: "outermost below ceiling", dir: filepath.Join(svc, "cmd"), opts: &rootOptions{outermost: true, markers: []string{"go.work"}}, ceilings: repo, want: goDir},
+		{name: "ceiling hides every root", dir: filepath.Join(svc, "cmd"), opts: &rootOptions{}, ceilings: svc, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PANAMA_CEILING_DIRECTORIES", tt.ceilings)
+			t.Setenv("GIT_CEILING_DIRECTORIES", "")
+			if err := os.Chdir(tt.dir); err != nil {
+				t.Fatal(err)
+			}
+			tt.opts.format = "path"
+
+			out, err := captureStdout(t, func() error { return runRoot(tt.opts) })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runRoot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := strings.TrimSpace(out); !tt.wantErr && got != tt.want {
+				t.Errorf("runRoot() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
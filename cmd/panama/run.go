@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/match"
+	"github.com/yuya-takeyama/panama/internal/pipeline"
+	"github.com/yuya-takeyama/panama/internal/preview"
+	"github.com/yuya-takeyama/panama/internal/runner"
+	"github.com/yuya-takeyama/panama/internal/shell"
+	"github.com/yuya-takeyama/panama/internal/ui/finder"
+	"github.com/yuya-takeyama/panama/internal/ui/fuzzyfinder"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+type runOptions struct {
+	maxDepth  int
+	noCache   bool
+	config    string
+	selection workspaceSelection
+	pick      bool
+	query     string
+	finder    string
+	list      bool
+	parallel  int
+	failFast  bool
+	json      bool
+}
+
+func newRunCommand() *cobra.Command {
+	opts := &runOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "run [task] [flags] [-- args...]",
+		Short: "Run a workspace task",
+		Long: `Run a package.json script, Makefile target, justfile recipe, Taskfile task
+or pyproject.toml script.
+
+With a task name, it runs in the workspace containing the current directory,
+in one chosen with --select, or in every workspace chosen with --type, --path
+and --where that defines it. Without a task name, a finder lists every
+workspace:task pair of the repository. Arguments after -- are passed to the
+task.`,
+		Example: `  panama run test
+  panama run build --type node -j 4
+  panama run dev --select
+  panama run
+  panama run --list`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			dash := cmd.ArgsLenAtDash()
+			if dash < 0 {
+				dash = len(args)
+			}
+			if dash > 1 {
+				return fmt.Errorf("accepts at most one task, received %d", dash)
+			}
+			if dash == 0 && len(args) > 0 {
+				return fmt.Errorf("arguments after -- need a task")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dash := cmd.ArgsLenAtDash()
+			if dash < 0 {
+				dash = len(args)
+			}
+			var task string
+			if dash > 0 {
+				task = args[0]
+			}
+			return runRun(task, args[dash:], opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&opts.maxDepth, "max-depth", 0, "Maximum search depth (0 uses config default)")
+	flags.BoolVar(&opts.noCache, "no-cache", false, "Disable caching")
+	flags.StringVar(&opts.config, "config", "", "Path to configuration file")
+	flags.StringSliceVarP(&opts.selection.types, "type", "t", nil, "Run in the workspaces of these package types, such as go or node")
+	flags.StringSliceVarP(&opts.selection.paths, "path", "p", nil, "Run in the workspaces whose relative path matches one of these globs")
+	flags.StringSliceVar(&opts.selection.owners, "owner", nil, "Run in the workspaces owned by one of these teams or people, such as @acme/web")
+	flags.StringSliceVar(&opts.selection.tags, "tag", nil, "Run in the workspaces with one of these tags")
+	flags.StringVarP(&opts.selection.where, "where", "w", "", "Run in the workspaces matching the query")
+	flags.BoolVarP(&opts.pick, "select", "s", false, "Choose the workspace to run the task in")
+	flags.StringVarP(&opts.query, "query", "q", "", "Initial search query")
+	flags.StringVar(&opts.finder, "finder", "", "Fuzzy finder to use: builtin, fzf, sk or peco (overrides config)")
+	flags.BoolVarP(&opts.list, "list", "l", false, "List the tasks instead of running one")
+	flags.IntVarP(&opts.parallel, "parallel", "j", runtime.NumCPU(), "Number of workspaces to run in at once")
+	flags.BoolVar(&opts.failFast, "fail-fast", false, "Stop at the first failure instead of running in every workspace")
+	flags.BoolVar(&opts.json, "json", false, "Print a JSON report on stdout; task output goes to stderr")
+
+	return cmd
+}
+
+func runRun(taskName string, args []string, opts *runOptions) error {
+	workspaces, cfg, searchRoot, describer, err := collectSelection(nil, opts.config, opts.maxDepth, opts.noCache, &opts.selection)
+	if err != nil {
+		return err
+	}
+	if opts.finder != "" {
+		cfg.Finder = opts.finder
+	}
+	describeAll(workspaces, describer, workspace.DetailTasks)
+
+	all := workspaces
+	if taskName != "" {
+		workspaces = withTask(workspaces, taskName)
+	}
+
+	if opts.list {
+		return listTasks(os.Stdout, workspaces, taskName, searchRoot)
+	}
+
+	switch {
+	case taskName == "":
+		ws, task, err := chooseTask(workspaces, cfg, describer, searchRoot, opts.query)
+		if err != nil {
+			return err
+		}
+		return runTask(ws, task, args, searchRoot, cfg.Silent)
+
+	case opts.pick:
+		if len(workspaces) == 0 {
+			return fmt.Errorf("no workspace defines task %q", taskName)
+		}
+		ws, err := chooseWorkspace(workspaces, cfg, describer, searchRoot, opts.query, taskName)
+		if err != nil {
+			return err
+		}
+		task, _ := findTask(ws, taskName)
+		return runTask(ws, task, args, searchRoot, cfg.Silent)
+
+	case !opts.selection.empty():
+		if len(workspaces) == 0 {
+			return fmt.Errorf("no selected workspace defines task %q", taskName)
+		}
+		jobs := make([]runner.Job, len(workspaces))
+		names := make([]string, len(workspaces))
+		for i, ws := range workspaces {
+			task, _ := findTask(ws, taskName)
+			jobs[i] = runner.Job{Workspace: ws, Command: []string{taskCommand(task, args)}}
+			names[i] = ws.Name
+		}
+		results := runJobs(jobs, names, opts.parallel, opts.failFast, opts.json)
+		return runner.Summary(results)
+	}
+
+	ws, err := currentWorkspace(all)
+	if err != nil {
+		return err
+	}
+	task, ok := findTask(ws, taskName)
+	if !ok {
+		return fmt.Errorf("%s has no task %q%s", ws.Name, taskName, availableTasks(ws))
+	}
+	return runTask(ws, task, args, searchRoot, cfg.Silent)
+}
+
+// withTask returns the workspaces defining the task called name
+func withTask(workspaces []*workspace.Workspace, name string) []*workspace.Workspace {
+	var found []*workspace.Workspace
+	for _, ws := range workspaces {
+		if _, ok := findTask(ws, name); ok {
+			found = append(found, ws)
+		}
+	}
+	return found
+}
+
+// findTask returns the first task of ws called name
+func findTask(ws *workspace.Workspace, name string) (workspace.Task, bool) {
+	for _, task := range ws.Tasks {
+		if task.Name == name {
+			return task, true
+		}
+	}
+	return workspace.Task{}, false
+}
+
+// availableTasks lists the task names of ws for an error message
+func availableTasks(ws *workspace.Workspace) string {
+	if len(ws.Tasks) == 0 {
+		return " (it defines none)"
+	}
+	names := make([]string, len(ws.Tasks))
+	for i, task := range ws.Tasks {
+		names[i] = task.Name
+	}
+	return " (available: " + strings.Join(names, ", ") + ")"
+}
+
+// currentWorkspace returns the innermost of workspaces containing the
+// current directory
+func currentWorkspace(workspaces []*workspace.Workspace) (*workspace.Workspace, error) {
+	cwd, err := filepath.Abs(".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+	current := innermostWorkspace(workspaces, cwd)
+	if current == nil {
+		return nil, fmt.Errorf("not inside a workspace; choose one with --select, or several with --type, --path or --where")
+	}
+	return current, nil
+}
+
+// taskCommand returns the shell command running task with args appended
+func taskCommand(task workspace.Task, args []string) string {
+	command := task.Command
+	for _, arg := range args {
+		command += " " + shell.Quote(arg)
+	}
+	return command
+}
+
+// runTask runs task in ws attached to the terminal
+func runTask(ws *workspace.Workspace, task workspace.Task, args []string, searchRoot string, silent bool) error {
+	command := taskCommand(task, args)
+	if !silent {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", ws.LabelWithBase(searchRoot), command)
+	}
+
+	cmd := shell.Command(context.Background(), command)
+	cmd.Dir = ws.Path
+	cmd.Env = append(os.Environ(), "PANAMA_WORKSPACE="+ws.Path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	// Ctrl+C reaches the task through the terminal; panama waits for it to
+	// exit instead of leaving it behind
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("task %s failed in %s: %w", task.Name, ws.Name, err)
+	}
+	return nil
+}
+
+// listTasks prints each workspace:task pair with its command, only those
+// called taskName when it is set
+func listTasks(w io.Writer, workspaces []*workspace.Workspace, taskName, searchRoot string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	found := false
+	for _, ws := range workspaces {
+		for _, task := range ws.Tasks {
+			if taskName != "" && task.Name != taskName {
+				continue
+			}
+			fmt.Fprintf(tw, "%s:%s\t%s\n", ws.LabelWithBase(searchRoot), task.Name, task.Command)
+			found = true
+		}
+	}
+	if !found {
+		if taskName != "" {
+			return fmt.Errorf("no workspace defines task %q", taskName)
+		}
+		return fmt.Errorf("no tasks found")
+	}
+	return tw.Flush()
+}
+
+// chooseTask lets the user pick one of the workspace:task pairs
+func chooseTask(workspaces []*workspace.Workspace, cfg *config.Config, describer *pipeline.Describer, searchRoot, query string) (*workspace.Workspace, workspace.Task, error) {
+	var renderer *preview.Renderer
+	if !cfg.Preview.Disabled {
+		renderer = preview.New(cfg.Preview, cfg.IgnoreDirs)
+	}
+
+	var (
+		items          []fuzzyfinder.Item
+		itemWorkspaces []*workspace.Workspace // Workspace of each item
+		tasks          []workspace.Task
+	)
+	for _, ws := range workspaces {
+		for _, task := range ws.Tasks {
+			item := fuzzyfinder.Item{
+				Label:       ws.LabelWithBase(searchRoot) + ":" + task.Name,
+				Description: task.Command,
+				Path:        ws.Path,
+			}
+			if renderer != nil {
+				item.Preview = func(width, height int) string {
+					_ = describer.Describe(ws, workspace.AllDetails)
+					return renderer.Render(ws)
+				}
+			}
+			items = append(items, item)
+			itemWorkspaces = append(itemWorkspaces, ws)
+			tasks = append(tasks, task)
+		}
+	}
+	if len(items) == 0 {
+		return nil, workspace.Task{}, fmt.Errorf("no tasks found")
+	}
+
+	// Labels such as web:dev look like query terms, so a query that does not
+	// parse is matched as text
+	filter := workspaceFilter(itemWorkspaces, searchRoot, describer)
+	textFallback := func(s string) (func(int) bool, string, error) {
+		keep, text, err := filter(s)
+		if err != nil {
+			return nil, s, nil
+		}
+		return keep, text, nil
+	}
+	idx, err := find(items, textFallback, cfg, "tasks > ", query)
+	if err != nil {
+		return nil, workspace.Task{}, err
+	}
+	return itemWorkspaces[idx], tasks[idx], nil
+}
+
+// chooseWorkspace lets the user pick the workspace to run taskName in
+func chooseWorkspace(workspaces []*workspace.Workspace, cfg *config.Config, describer *pipeline.Describer, searchRoot, query, taskName string) (*workspace.Workspace, error) {
+	idx, err := find(finderItems(workspaces, searchRoot, cfg, describer), workspaceFilter(workspaces, searchRoot, describer), cfg, taskName+" > ", query)
+	if err != nil {
+		return nil, err
+	}
+	return workspaces[idx], nil
+}
+
+// find runs the configured finder over items and returns the chosen index
+func find(items []fuzzyfinder.Item, filter func(string) (func(int) bool, string, error), cfg *config.Config, prompt, query string) (int, error) {
+	f, err := finder.New(cfg.Finder, cfg.FinderOpts)
+	if err != nil {
+		return 0, err
+	}
+	matcher, err := match.New(cfg.Matcher)
+	if err != nil {
+		return 0, err
+	}
+	result, err := f.Find(items, fuzzyfinder.Options{
+		Prompt:  prompt,
+		Query:   query,
+		Matcher: matcher,
+		Filter:  filter,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(result.Indices) == 0 {
+		return 0, fuzzyfinder.ErrAbort
+	}
+	return result.Indices[0], nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupTasks creates workspaces with a Makefile echoing the target and
+// workspace names
+func setupTasks(t *testing.T, names ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("make"); err != nil {
+		t.Skip("make not available")
+	}
+	tmpDir := setupWorkspaces(t, names...)
+	for _, name := range names {
+		makefile := "build test:\n\t@echo $@ $(notdir $(CURDIR)) $(ARGS)\n"
+		if err := os.WriteFile(filepath.Join(tmpDir, name, "Makefile"), []byte(makefile), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tmpDir
+}
+
+func TestRun(t *testing.T) {
+	tmpDir := setupTasks(t, "apps/web", "services/api")
+
+	run := func(task string, args []string, opts *runOptions) (string, error) {
+		t.Helper()
+		opts.noCache, opts.parallel = true, 1
+		return captureStdout(t, func() error { return runRun(task, args, opts) })
+	}
+
+	t.Run("list", func(t *testing.T) {
+		out, err := run("", nil, &runOptions{list: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "apps/web:build      make build\napps/web:test       make test\nservices/api:build  make build\nservices/api:test   make test\n"
+		if out != want {
+			t.Errorf("output = %q, want %q", out, want)
+		}
+	})
+
+	t.Run("current workspace", func(t *testing.T) {
+		if err := os.Chdir(filepath.Join(tmpDir, "services", "api")); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Chdir(tmpDir)
+
+		out, err := run("test", []string{"ARGS=-v"}, &runOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "test api -v\n" {
+			t.Errorf("output = %q", out)
+		}
+
+		_, err = run("deploy", nil, &runOptions{})
+		if err == nil || !strings.Contains(err.Error(), "available: build, test") {
+			t.Errorf("unknown task error = %v", err)
+		}
+	})
+
+	t.Run("outside a workspace", func(t *testing.T) {
+		if _, err := run("test", nil, &runOptions{}); err == nil || !strings.Contains(err.Error(), "not inside a workspace") {
+			t.Errorf("error = %v", err)
+		}
+	})
+
+	t.Run("selection", func(t *testing.T) {
+		out, err := run("build", nil, &runOptions{selection: workspaceSelection{types: []string{"go"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "web | build web\napi | build api\n" {
+			t.Errorf("output = %q", out)
+		}
+	})
+
+	t.Run("finder", func(t *testing.T) {
+		out, err := run("", nil, &runOptions{query: "api:test"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "test api\n" {
+			t.Errorf("output = %q", out)
+		}
+	})
+
+	t.Run("select workspace", func(t *testing.T) {
+		out, err := run("build", nil, &runOptions{pick: true, query: "path:apps/*"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "build web\n" {
+			t.Errorf("output = %q", out)
+		}
+	})
+}
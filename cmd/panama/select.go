@@ -1,16 +1,26 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/drill"
+	"github.com/yuya-takeyama/panama/internal/match"
+	"github.com/yuya-takeyama/panama/internal/order"
 	"github.com/yuya-takeyama/panama/internal/output"
 	"github.com/yuya-takeyama/panama/internal/pipeline"
+	"github.com/yuya-takeyama/panama/internal/preview"
+	"github.com/yuya-takeyama/panama/internal/ui/finder"
 	"github.com/yuya-takeyama/panama/internal/ui/fuzzyfinder"
-	"golang.org/x/term"
+	"github.com/yuya-takeyama/panama/internal/workspace"
 )
 
 type selectOptions struct {
@@ -20,6 +30,17 @@ type selectOptions struct {
 	noCache  bool
 	silent   bool
 	config   string
+	verbose  bool
+
+	multi         bool
+	preselect     []string
+	preselectFrom string
+	printAction   bool
+	finder        string
+	drill         string
+	sort          string
+	reverse       bool
+	group         string
 }
 
 func newSelectCommand() *cobra.Command {
@@ -28,7 +49,7 @@ func newSelectCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "select [path]",
 		Short: "Select a workspace interactively",
-		Long: `Select a workspace using the built-in fuzzy finder.
+		Long: `Select a workspace using the built-in fuzzy finder, or fzf, sk or peco.
 If no path is provided, it searches from the current directory.`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -38,11 +59,22 @@ If no path is provided, it searches from the current directory.`,
 
 	flags := cmd.Flags()
 	flags.StringVarP(&opts.query, "query", "q", "", "Initial search query")
-	flags.StringVarP(&opts.format, "format", "f", "path", "Output format (path|cd|json)")
+	flags.StringVarP(&opts.format, "format", "f", "path", "Output format (path|cd|json|nul)")
 	flags.IntVar(&opts.maxDepth, "max-depth", 0, "Maximum search depth (0 uses config default)")
 	flags.BoolVar(&opts.noCache, "no-cache", false, "Disable caching")
 	flags.BoolVar(&opts.silent, "silent", false, "Suppress non-essential output")
 	flags.StringVar(&opts.config, "config", "", "Path to configuration file")
+	flags.BoolVarP(&opts.verbose, "verbose", "v", false, "Report unreadable directories and other walk errors")
+	flags.BoolVarP(&opts.multi, "multi", "m", false, "Select multiple workspaces (Tab to toggle)")
+	flags.StringSliceVar(&opts.preselect, "preselect", nil, "Workspace paths to select initially in --multi mode")
+	flags.StringVar(&opts.preselectFrom, "preselect-from", "", "File with workspace paths to select initially: one per line, NUL-separated or JSON from -f json")
+	flags.StringVar(&opts.finder, "finder", "", "Fuzzy finder to use: builtin, fzf, sk or peco (overrides config)")
+	flags.StringVar(&opts.drill, "drill", "", "After choosing a workspace, choose a file or directory inside it (all|dirs|files)")
+	flags.Lookup("drill").NoOptDefVal = string(drill.KindAll)
+	flags.BoolVar(&opts.printAction, "print-action", false, "Print the name of the action that ended the finder (\"accept\" for Enter) before the selection")
+	flags.StringVar(&opts.sort, "sort", "", "Sort order: path, name, depth, modified, commit or frecency (overrides config)")
+	flags.BoolVar(&opts.reverse, "reverse", false, "Reverse the sort order")
+	flags.StringVar(&opts.group, "group", "", "Group workspaces in the built-in finder: none, dir or type (overrides config)")
 
 	return cmd
 }
@@ -85,44 +117,316 @@ func runSelect(args []string, opts *selectOptions) error {
 		searchRoot = cfg.ConfigDir
 	}
 
-	workspaces, err := pipeline.CollectWorkspaces(searchRoot, cfg, pipelineOpts)
+	sortOpts, err := sortOptions(cfg, opts.sort, opts.reverse)
+	if err != nil {
+		return err
+	}
+
+	var describer *pipeline.Describer
+	collect := func() ([]*workspace.Workspace, error) {
+		result, err := pipeline.Collect(searchRoot, cfg, pipelineOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect workspaces: %w", err)
+		}
+		describer = pipeline.NewDescriber(cfg)
+		if opts.verbose {
+			result.Describe(describer, workspace.DetailMetadata)
+			printWalkReport(result, searchRoot)
+		}
+		if len(result.Workspaces) == 0 {
+			return nil, fmt.Errorf("no workspaces found")
+		}
+		order.Sort(result.Workspaces, sortOpts)
+		return result.Workspaces, nil
+	}
+
+	if opts.multi && format == output.FormatCD {
+		return fmt.Errorf("format cd is not supported with --multi")
+	}
+
+	drillKind := drill.KindAll
+	if opts.drill != "" {
+		if opts.multi {
+			return fmt.Errorf("--drill cannot be combined with --multi")
+		}
+		if drillKind, err = drill.ParseKind(opts.drill); err != nil {
+			return err
+		}
+	}
+
+	if !opts.multi && (len(opts.preselect) > 0 || opts.preselectFrom != "") {
+		return fmt.Errorf("--preselect and --preselect-from require --multi")
+	}
+
+	var preselected []string
+	if opts.multi {
+		preselected, err = loadPreselection(opts.preselect, opts.preselectFrom)
+		if err != nil {
+			return err
+		}
+	}
+
+	workspaces, err := collect()
 	if err != nil {
-		return fmt.Errorf("failed to collect workspaces: %w", err)
+		return err
 	}
 
-	if len(workspaces) == 0 {
-		return fmt.Errorf("no workspaces found")
+	if opts.finder != "" {
+		cfg.Finder = opts.finder
+	}
+	if opts.group != "" {
+		if !slices.Contains(config.Groupings, opts.group) {
+			return fmt.Errorf("invalid group: %s (must be one of: %s)", opts.group, strings.Join(config.Groupings, ", "))
+		}
+		cfg.Group = opts.group
+	}
+	f, err := finder.New(cfg.Finder, cfg.FinderOpts)
+	if err != nil {
+		return err
 	}
 
-	// Check if we should use interactive mode
-	// Only check stdin as fuzzyfinder uses /dev/tty directly
-	isInteractive := term.IsTerminal(int(os.Stdin.Fd()))
+	matcher, err := match.New(cfg.Matcher)
+	if err != nil {
+		return err
+	}
 
-	var selectedPath string
+	finderOpts := fuzzyfinder.Options{
+		Prompt:  "workspaces > ",
+		Query:   opts.query,
+		Multi:   opts.multi,
+		Header:  actionHeader(cfg.Actions),
+		Keys:    actionKeys(cfg.Actions),
+		Matcher: matcher,
+	}
+	if opts.multi {
+		finderOpts.Prompt = "workspaces (tab to select) > "
+	}
 
-	if isInteractive {
-		// Convert to fuzzyfinder items
-		items := make([]fuzzyfinder.Item, len(workspaces))
-		for i, ws := range workspaces {
-			items[i] = fuzzyfinder.Item{
-				Label:       ws.LabelWithBase(searchRoot),
-				Description: ws.Description,
-				Path:        ws.Path,
+	// Non-interactive mode returns the preselection or the first workspace
+	for {
+		if len(preselected) > 0 {
+			finderOpts.Preselected = func(i int) bool {
+				return slices.Contains(preselected, workspaces[i].Path)
 			}
 		}
+		finderOpts.Filter = workspaceFilter(workspaces, searchRoot, describer)
 
-		// Show fuzzy finder
-		idx, err := fuzzyfinder.Select(items, opts.query)
+		result, err := f.Find(finderItems(workspaces, searchRoot, cfg, describer), finderOpts)
 		if err != nil {
 			return err
 		}
 
-		selectedPath = workspaces[idx].Path
-	} else {
-		// Non-interactive mode
-		selectedPath = workspaces[0].Path
+		selected := make([]*workspace.Workspace, len(result.Indices))
+		for i, idx := range result.Indices {
+			selected[i] = workspaces[idx]
+		}
+
+		// Actions returning to the finder keep the query and, when picking a
+		// single workspace, the highlighted item
+		finderOpts.Query = result.Query
+		if !opts.multi && len(selected) > 0 {
+			preselected = []string{selected[0].Path}
+		}
+
+		action := findAction(cfg.Actions, result.Key)
+		if action != nil && action.Builtin == config.BuiltinRescan {
+			if workspaces, err = collect(); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(selected) == 0 {
+			// A bound key was pressed with nothing matching
+			continue
+		}
+
+		if (action == nil && opts.drill != "") || (action != nil && action.Builtin == config.BuiltinDrill) {
+			path, err := drillDown(f, selected[0], cfg, drillKind)
+			if errors.Is(err, errBack) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			recordSelection(selected, opts.silent || cfg.Silent)
+			printActionName(action, format, opts)
+			return output.Print(path, format)
+		}
+
+		if action != nil && action.Run != "" {
+			// Actions are given the editor from the metadata
+			describeAll(selected, describer, workspace.DetailMetadata)
+			if err := runAction(action, selected); err != nil {
+				return err
+			}
+			if action.Return {
+				continue
+			}
+		}
+
+		recordSelection(selected, opts.silent || cfg.Silent)
+		printActionName(action, format, opts)
+		if opts.multi {
+			return printWorkspaces(selected, format, describer)
+		}
+		return output.Print(selected[0].Path, format)
+	}
+}
+
+// printActionName prints the name of the action that ended the finder when
+// --print-action is set
+func printActionName(action *config.Action, format output.Format, opts *selectOptions) {
+	if !opts.printAction {
+		return
+	}
+	name := acceptAction
+	if action != nil {
+		name = action.Label()
+	}
+	terminator := "\n"
+	if format == output.FormatNUL {
+		terminator = "\x00"
+	}
+	fmt.Print(name + terminator)
+}
+
+// finderItems converts workspaces to fuzzy finder items with a preview
+// rendered on demand for the highlighted workspace. Details are filled in
+// by describer when the preview or the keywords are needed.
+func finderItems(workspaces []*workspace.Workspace, searchRoot string, cfg *config.Config, describer *pipeline.Describer) []fuzzyfinder.Item {
+	var renderer *preview.Renderer
+	if !cfg.Preview.Disabled {
+		renderer = preview.New(cfg.Preview, cfg.IgnoreDirs)
+	}
+
+	items := make([]fuzzyfinder.Item, len(workspaces))
+	for i, ws := range workspaces {
+		items[i] = fuzzyfinder.Item{
+			Label: ws.LabelWithBase(searchRoot),
+			Path:  ws.Path,
+			Keywords: func() []string {
+				_ = describer.Describe(ws, workspace.DetailMetadata)
+				return append(slices.Clone(ws.Aliases), ws.Tags...)
+			},
+			Preview: func(width, height int) string {
+				if renderer == nil {
+					// The description may come from the metadata
+					_ = describer.Describe(ws, workspace.DetailMetadata)
+					return fmt.Sprintf("Path: %s\n\nDescription:\n%s", ws.Path, ws.Description)
+				}
+				_ = describer.Describe(ws, workspace.AllDetails)
+				return renderer.Render(ws)
+			},
+		}
+	}
+	if cfg.Group == "dir" || cfg.Group == "type" {
+		groupItems(items, workspaces, searchRoot, cfg.Group)
+	}
+	return items
+}
+
+// groupItems puts each workspace under its top-level directory (by "dir"),
+// indented by its depth below that directory, or under its package type (by
+// "type"), nested under the workspaces of the same type containing it
+func groupItems(items []fuzzyfinder.Item, workspaces []*workspace.Workspace, searchRoot, by string) {
+	groups := make(map[string]string, len(workspaces))
+	for i, ws := range workspaces {
+		group := ws.Type
+		if by == "dir" {
+			var below string
+			group, below, _ = strings.Cut(filepath.ToSlash(ws.RelativePath(searchRoot)), "/")
+			if below != "" {
+				items[i].Indent = strings.Count(below, "/") + 1
+			}
+		}
+		if group == "" {
+			group = "other"
+		}
+		items[i].Group = group
+		groups[ws.Path] = group
+	}
+	if by == "dir" {
+		return
+	}
+
+	for i, ws := range workspaces {
+		for dir := filepath.Dir(ws.Path); len(dir) >= len(searchRoot) && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+			if groups[dir] == items[i].Group {
+				items[i].Indent++
+			}
+		}
+	}
+}
+
+// loadPreselection returns the absolute paths given with --preselect and
+// read from the --preselect-from file, such as the output of a previous run
+func loadPreselection(paths []string, file string) ([]string, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read preselection: %w", err)
+		}
+		listed, err := preselectionPaths(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read preselection from %s: %w", file, err)
+		}
+		paths = append(paths, listed...)
+	}
+
+	preselected := make([]string, 0, len(paths))
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve path: %w", err)
+		}
+		preselected = append(preselected, absPath)
 	}
+	return preselected, nil
+}
 
-	// Output the selected path
-	return output.Print(selectedPath, format)
+// preselectionPaths returns the paths listed in data: the JSON printed by
+// select or list with -f json, or a list read by splitPaths
+func preselectionPaths(data []byte) ([]string, error) {
+	type entry struct {
+		Path string `json:"path"`
+	}
+	var entries []entry
+	switch trimmed := bytes.TrimSpace(data); {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		entries = append(entries, entry{})
+		if err := json.Unmarshal(trimmed, &entries[0]); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	default:
+		return splitPaths(data), nil
+	}
+
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		if e.Path == "" {
+			return nil, fmt.Errorf("JSON entry %d has no path", i+1)
+		}
+		paths[i] = e.Path
+	}
+	return paths, nil
+}
+
+// splitPaths splits a list of paths separated by newlines, or by NUL bytes
+// when there are any
+func splitPaths(data []byte) []string {
+	sep := "\n"
+	if bytes.Contains(data, []byte{0}) {
+		sep = "\x00"
+	}
+	var paths []string
+	for _, line := range strings.Split(string(data), sep) {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths
 }
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/pipeline"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// captureStdout runs fn and returns what it wrote to stdout
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := fn()
+
+	w.Close()
+	os.Stdout = oldStdout
+	out, _ := io.ReadAll(r)
+	return string(out), err
+}
+
+// setupWorkspaces creates a config and one go.mod workspace per name, and
+// changes into the new directory for the duration of the test
+func setupWorkspaces(t *testing.T, names ...string) string {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	// Keep the selection history out of the home directory
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	if err := os.WriteFile(filepath.Join(tmpDir, ".panama.yaml"), []byte("patterns:\n  - go.mod\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		dir := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalDir) })
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Resolve symlinks such as /tmp on macOS so paths match the pipeline
+	resolved, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolved
+}
+
+func TestSelectMulti(t *testing.T) {
+	tmpDir := setupWorkspaces(t, "alpha", "beta", "gamma")
+
+	selectionFile := filepath.Join(tmpDir, "selection")
+	content := filepath.Join(tmpDir, "gamma") + "\x00" + filepath.Join(tmpDir, "alpha") + "\x00"
+	if err := os.WriteFile(selectionFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	jsonFile := filepath.Join(tmpDir, "selection.json")
+	content = `[{"name": "beta", "path": "` + filepath.Join(tmpDir, "beta") + `"}, {"path": "gamma"}]`
+	if err := os.WriteFile(jsonFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	invalidFile := filepath.Join(tmpDir, "invalid.json")
+	if err := os.WriteFile(invalidFile, []byte(`[{"name": "beta"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    *selectOptions
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "preselect flag",
+			opts: &selectOptions{multi: true, format: "json", preselect: []string{"beta", "alpha"}},
+			want: []string{"alpha", "beta"},
+		},
+		{
+			name: "preselect from NUL-delimited file",
+			opts: &selectOptions{multi: true, format: "json", preselectFrom: selectionFile},
+			want: []string{"alpha", "gamma"},
+		},
+		{
+			name: "first workspace without preselection",
+			opts: &selectOptions{multi: true, format: "json"},
+			want: []string{"alpha"},
+		},
+		{
+			name: "preselect from JSON output",
+			opts: &selectOptions{multi: true, format: "json", preselectFrom: jsonFile},
+			want: []string{"beta", "gamma"},
+		},
+		{
+			name:    "JSON without paths is rejected",
+			opts:    &selectOptions{multi: true, format: "json", preselectFrom: invalidFile},
+			wantErr: true,
+		},
+		{
+			name:    "preselect without multi is rejected",
+			opts:    &selectOptions{format: "json", preselect: []string{"beta"}},
+			wantErr: true,
+		},
+		{
+			name:    "cd format is rejected",
+			opts:    &selectOptions{multi: true, format: "cd"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := captureStdout(t, func() error {
+				return runSelect(nil, tt.opts)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runSelect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var got []struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("expected a JSON array, got %q: %v", out, err)
+			}
+			names := make([]string, len(got))
+			for i, ws := range got {
+				names[i] = ws.Name
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("selected = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectPrintAction(t *testing.T) {
+	tmpDir := setupWorkspaces(t, "alpha", "beta")
+
+	out, err := captureStdout(t, func() error {
+		return runSelect(nil, &selectOptions{format: "path", printAction: true})
+	})
+	if err != nil {
+		t.Fatalf("runSelect() error = %v", err)
+	}
+
+	want := "accept\n" + filepath.Join(tmpDir, "alpha") + "\n"
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestSelectQuery(t *testing.T) {
+	tmpDir := setupWorkspaces(t, "services/capital", "services/api")
+	if err := os.WriteFile(filepath.Join(tmpDir, ".panama.yaml"), []byte("patterns:\n  - go.mod\nmatcher: smart\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := captureStdout(t, func() error {
+		return runSelect(nil, &selectOptions{format: "path", query: "api"})
+	})
+	if err != nil {
+		t.Fatalf("runSelect() error = %v", err)
+	}
+	if want := filepath.Join(tmpDir, "services", "api") + "\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestSelectHistory(t *testing.T) {
+	tmpDir := setupWorkspaces(t, "alpha", "beta")
+	historyPath := filepath.Join(os.Getenv("XDG_STATE_HOME"), "panama", "history.json")
+
+	// Selections are recorded in any order, ready for frecency
+	if _, err := captureStdout(t, func() error {
+		return runSelect(nil, &selectOptions{format: "path", query: "beta", sort: "name"})
+	}); err != nil {
+		t.Fatalf("runSelect() error = %v", err)
+	}
+	data, err := os.ReadFile(historyPath)
+	if err != nil {
+		t.Fatalf("history not written: %v", err)
+	}
+	if beta, _ := json.Marshal(filepath.Join(tmpDir, "beta")); !strings.Contains(string(data), string(beta)) {
+		t.Errorf("history = %s, want beta recorded", data)
+	}
+}
+
+func TestFindAction(t *testing.T) {
+	actions := []config.Action{
+		{Key: "ctrl-e", Name: "edit", Run: "vi ."},
+		{Key: "Ctrl+T", Name: "test", Run: "make test"},
+		{Key: "ctrl-e", Name: "code", Run: "code ."},
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"ctrl-e", "code"},
+		{"ctrl-t", "test"},
+		{"ctrl-x", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		action := findAction(actions, tt.key)
+		got := ""
+		if action != nil {
+			got = action.Name
+		}
+		if got != tt.want {
+			t.Errorf("findAction(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSelectDrill(t *testing.T) {
+	tmpDir := setupWorkspaces(t, "alpha", "beta")
+
+	tests := []struct {
+		name    string
+		opts    *selectOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "directories start with the workspace",
+			opts: &selectOptions{format: "path", drill: "all"},
+			want: filepath.Join(tmpDir, "alpha") + "\n",
+		},
+		{
+			name: "files",
+			opts: &selectOptions{format: "path", drill: "files"},
+			want: filepath.Join(tmpDir, "alpha", "go.mod") + "\n",
+		},
+		{
+			name:    "invalid kind",
+			opts:    &selectOptions{format: "path", drill: "links"},
+			wantErr: true,
+		},
+		{
+			name:    "multi",
+			opts:    &selectOptions{format: "path", drill: "all", multi: true},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := captureStdout(t, func() error {
+				return runSelect(nil, tt.opts)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runSelect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out != tt.want {
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupItems(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	workspaces := []*workspace.Workspace{
+		{Path: root, Name: "repo"},
+		{Path: filepath.Join(root, "apps"), Name: "apps", Type: "node"},
+		{Path: filepath.Join(root, "apps", "web"), Name: "web", Type: "node"},
+		{Path: filepath.Join(root, "apps", "web", "e2e"), Name: "e2e", Type: "node"},
+		{Path: filepath.Join(root, "services", "api"), Name: "api", Type: "go"},
+		{Path: filepath.Join(root, "services", "api", "client"), Name: "client", Type: "node"},
+	}
+
+	tests := []struct {
+		by         string
+		wantGroups []string
+		wantIndent []int
+	}{
+		{
+			by:         "dir",
+			wantGroups: []string{".", "apps", "apps", "apps", "services", "services"},
+			wantIndent: []int{0, 0, 1, 2, 1, 2},
+		},
+		{
+			by:         "type",
+			wantGroups: []string{"other", "node", "node", "node", "go", "node"},
+			wantIndent: []int{0, 0, 1, 2, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			items := finderItems(workspaces, root, &config.Config{Group: tt.by, Preview: config.PreviewConfig{Disabled: true}}, nil)
+			for i, item := range items {
+				if item.Group != tt.wantGroups[i] || item.Indent != tt.wantIndent[i] {
+					t.Errorf("%s: group %q indent %d, want %q and %d", item.Label, item.Group, item.Indent, tt.wantGroups[i], tt.wantIndent[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGroupItems_NestedLayout(t *testing.T) {
+	tmpDir := setupWorkspaces(t, "apps/web", "services/api", "services/legacy/billing", "tools")
+
+	cfg := config.Load("", tmpDir)
+	cfg.Group = "dir"
+	cfg.Preview.Disabled = true
+	workspaces, err := pipeline.CollectWorkspaces(tmpDir, cfg, pipeline.Options{NoCache: true})
+	if err != nil {
+		t.Fatalf("CollectWorkspaces() error = %v", err)
+	}
+
+	want := map[string]struct {
+		group  string
+		indent int
+	}{
+		"apps/web":                {"apps", 1},
+		"services/api":            {"services", 1},
+		"services/legacy/billing": {"services", 2},
+		"tools":                   {"tools", 0},
+	}
+	items := finderItems(workspaces, tmpDir, cfg, nil)
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for _, item := range items {
+		w := want[filepath.ToSlash(item.Label)]
+		if item.Group != w.group || item.Indent != w.indent {
+			t.Errorf("%s: group %q indent %d, want %q and %d", item.Label, item.Group, item.Indent, w.group, w.indent)
+		}
+	}
+}
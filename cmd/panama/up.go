@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/yuya-takeyama/panama/internal/output"
+)
+
+type upOptions struct {
+	format string
+	config string
+	list   bool
+}
+
+func newUpCommand() *cobra.Command {
+	opts := &upOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "up [n]",
+		Short: "Print the nearest enclosing workspace",
+		Long: `Print the nearest workspace containing the current directory, as the
+configured patterns define it, or the nth one counting outwards. The current
+directory counts when it is a workspace itself.
+
+Unlike root, which finds the repository root, up stops at the package you are
+working in. --list prints every enclosing workspace, nearest first.`,
+		Example: `  cd "$(panama up)"
+  panama up 2
+  panama up --list -f json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUp(args, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.format, "format", "f", "path", "Output format (path|cd|json|nul)")
+	flags.StringVar(&opts.config, "config", "", "Path to configuration file")
+	flags.BoolVarP(&opts.list, "list", "l", false, "Print every enclosing workspace, nearest first")
+
+	return cmd
+}
+
+func runUp(args []string, opts *upOptions) error {
+	format, err := output.ParseFormat(opts.format)
+	if err != nil {
+		return err
+	}
+
+	n := 1
+	if len(args) > 0 {
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+			return fmt.Errorf("invalid count: %s (must be a positive number)", args[0])
+		}
+	}
+
+	cwd, err := filepath.Abs(".")
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+	r, err := newResolverCache(opts.config, 0).get(cwd)
+	if err != nil {
+		return err
+	}
+	ancestors, err := r.Ancestors(cwd)
+	if err != nil {
+		return err
+	}
+	if len(ancestors) == 0 {
+		return fmt.Errorf("no enclosing workspace found")
+	}
+
+	if opts.list {
+		return printWorkspaces(ancestors, format, r.Describer())
+	}
+	if n > len(ancestors) {
+		return fmt.Errorf("only %d enclosing workspaces found", len(ancestors))
+	}
+	return output.Print(ancestors[n-1].Path, format)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUp(t *testing.T) {
+	tmpDir := setupWorkspaces(t, "services/api", "services/api/plugins/auth")
+	deep := filepath.Join(tmpDir, "services", "api", "plugins", "auth", "internal", "token")
+	if err := os.MkdirAll(deep, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(deep); err != nil {
+		t.Fatal(err)
+	}
+
+	auth := filepath.Join(tmpDir, "services", "api", "plugins", "auth")
+	api := filepath.Join(tmpDir, "services", "api")
+
+	tests := []struct {
+		name    string
+		args    []string
+		opts    upOptions
+		want    string
+		wantErr bool
+	}{
+		{name: "nearest", want: auth + "\n"},
+		{name: "second", args: []string{"2"}, want: api + "\n"},
+		{name: "list", opts: upOptions{list: true}, want: auth + "\n" + api + "\n"},
+		{name: "cd format", opts: upOptions{format: "cd"}, want: `cd "` + auth + `"` + "\n"},
+		{name: "beyond the outermost", args: []string{"3"}, wantErr: true},
+		{name: "invalid count", args: []string{"0"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.opts.format == "" {
+				tt.opts.format = "path"
+			}
+			out, err := captureStdout(t, func() error { return runUp(tt.args, &tt.opts) })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runUp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out != tt.want {
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/yuya-takeyama/panama/internal/match"
+	"github.com/yuya-takeyama/panama/internal/pipeline"
+	"github.com/yuya-takeyama/panama/internal/query"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// workspaceFilter returns a finder filter that applies the structured terms
+// of the query to workspaces and leaves the free text to fuzzy matching
+func workspaceFilter(workspaces []*workspace.Workspace, searchRoot string, describer *pipeline.Describer) func(string) (func(int) bool, string, error) {
+	matcher := query.NewMatcher(searchRoot, describer)
+	return func(s string) (func(int) bool, string, error) {
+		q, err := query.Parse(s)
+		if err != nil {
+			return nil, "", err
+		}
+		if !q.Structured() {
+			return nil, q.Text, nil
+		}
+
+		keep := make([]bool, len(workspaces))
+		for i, ws := range workspaces {
+			if keep[i], err = matcher.Match(q, ws); err != nil {
+				return nil, "", err
+			}
+		}
+		return func(i int) bool { return keep[i] }, q.Text, nil
+	}
+}
+
+// workspaceSelection holds the flags choosing workspaces for commands run
+// across them
+type workspaceSelection struct {
+	types  []string // Package type globs; any may match
+	paths  []string // Relative path globs; any may match
+	owners []string // Owner globs; any may match
+	tags   []string // Tag globs; any may match
+	where  string   // Query all workspaces must match
+}
+
+// empty reports whether no selection flag was given
+func (s *workspaceSelection) empty() bool {
+	return len(s.types) == 0 && len(s.paths) == 0 && len(s.owners) == 0 && len(s.tags) == 0 && s.where == ""
+}
+
+// filter returns the selected workspaces, keeping their order. The details
+// the selection looks at are filled in by describer, and the free text of
+// --where is matched by text.
+func (s *workspaceSelection) filter(workspaces []*workspace.Workspace, searchRoot string, describer *pipeline.Describer, text match.Matcher) ([]*workspace.Workspace, error) {
+	anyOf := func(key string, values []string) ([]*query.Query, error) {
+		queries := make([]*query.Query, len(values))
+		for i, value := range values {
+			q, err := query.Parse(key + ":" + value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --%s: %w", key, err)
+			}
+			queries[i] = q
+		}
+		return queries, nil
+	}
+	types, err := anyOf("type", s.types)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := anyOf("path", s.paths)
+	if err != nil {
+		return nil, err
+	}
+	owners, err := anyOf("owner", s.owners)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := anyOf("tag", s.tags)
+	if err != nil {
+		return nil, err
+	}
+	where, err := query.Parse(s.where)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --where query: %w", err)
+	}
+
+	matcher := query.NewMatcher(searchRoot, describer)
+	matchesAny := func(queries []*query.Query, ws *workspace.Workspace) (bool, error) {
+		if len(queries) == 0 {
+			return true, nil
+		}
+		for _, q := range queries {
+			if ok, err := matcher.Match(q, ws); ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+
+	var selected []*workspace.Workspace
+	for _, ws := range workspaces {
+		ok, err := matchesAny(types, ws)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			ok, err = matchesAny(paths, ws)
+		}
+		if ok && err == nil {
+			ok, err = matchesAny(owners, ws)
+		}
+		if ok && err == nil {
+			ok, err = matchesAny(tags, ws)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			selected = append(selected, ws)
+		}
+	}
+	return matcher.Filter(where, selected, text)
+}
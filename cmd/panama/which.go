@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/yuya-takeyama/panama/internal/changes"
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/output"
+	"github.com/yuya-takeyama/panama/internal/pipeline"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+type whichOptions struct {
+	format   string
+	maxDepth int
+	config   string
+	all      bool
+	relative bool
+	stdin    bool
+	silent   bool
+}
+
+func newWhichCommand() *cobra.Command {
+	opts := &whichOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "which <file>...",
+		Short: "Print the workspace a file belongs to",
+		Long: `Print the workspace each file belongs to, found by walking from the search
+root down to the file with the same configuration as list, without searching
+the rest of the tree. Files need not exist, so deleted files resolve too.
+
+The path formats print each workspace once, in the order of the files; the
+JSON format reports the workspace of every file. Without a configuration
+file, the search root is the enclosing git work tree.`,
+		Example: `  panama which services/api/main.go
+  git diff --name-only -z | panama which --stdin -f nul --relative
+  panama which --all -f json src/index.ts`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWhich(args, os.Stdin, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.format, "format", "f", "path", "Output format (path|cd|json|nul)")
+	flags.IntVar(&opts.maxDepth, "max-depth", 0, "Maximum search depth (0 uses config default)")
+	flags.StringVar(&opts.config, "config", "", "Path to configuration file")
+	flags.BoolVarP(&opts.all, "all", "a", false, "Also print the workspaces enclosing the innermost one")
+	flags.BoolVarP(&opts.relative, "relative", "r", false, "Print workspace paths relative to the search root")
+	flags.BoolVar(&opts.stdin, "stdin", false, "Read files from stdin, one per line or NUL-separated")
+	flags.BoolVar(&opts.silent, "silent", false, "Do not report files in no workspace")
+
+	return cmd
+}
+
+// whichResult is an entry of the JSON output of which
+type whichResult struct {
+	File      string                 `json:"file"`
+	Root      string                 `json:"root,omitempty"`
+	Workspace *workspace.Workspace   `json:"workspace"`
+	Enclosing []*workspace.Workspace `json:"enclosing,omitempty"` // Outermost first, with --all
+	Error     string                 `json:"error,omitempty"`
+}
+
+func runWhich(files []string, stdin io.Reader, opts *whichOptions) error {
+	format, err := output.ParseFormat(opts.format)
+	if err != nil {
+		return err
+	}
+
+	if opts.stdin {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		files = append(files, splitPaths(data)...)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files given")
+	}
+
+	resolvers := newResolverCache(opts.config, opts.maxDepth)
+
+	results := make([]whichResult, len(files))
+	for i, file := range files {
+		results[i] = resolveFile(file, resolvers)
+		if !opts.all && results[i].Workspace != nil {
+			results[i].Enclosing = nil
+		}
+	}
+
+	if format == output.FormatJSON {
+		for _, r := range results {
+			if r.Workspace != nil {
+				describeAll(r.Enclosing, resolvers.byRoot[r.Root].Describer(), workspace.AllDetails)
+			}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if r.Workspace == nil {
+			if !opts.silent {
+				fmt.Fprintf(os.Stderr, "warning: %s\n", r.Error)
+			}
+			continue
+		}
+		workspaces := []*workspace.Workspace{r.Workspace}
+		if opts.all {
+			workspaces = r.Enclosing
+		}
+		for _, ws := range workspaces {
+			path := ws.Path
+			if opts.relative {
+				path = ws.RelativePath(r.Root)
+			}
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	if len(paths) == 0 {
+		return fmt.Errorf("no workspace found")
+	}
+	if format == output.FormatCD && len(paths) > 1 {
+		return fmt.Errorf("format cd needs a single workspace, found %d", len(paths))
+	}
+	for _, path := range paths {
+		if err := output.Print(path, format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveFile finds the workspaces containing file
+func resolveFile(file string, resolvers *resolverCache) whichResult {
+	result := whichResult{File: file}
+
+	path, err := filepath.Abs(file)
+	if err != nil {
+		result.Error = fmt.Sprintf("%s: %v", file, err)
+		return result
+	}
+	dir := path
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+
+	r, err := resolvers.get(dir)
+	if err != nil {
+		result.Error = fmt.Sprintf("%s: %v", file, err)
+		return result
+	}
+	result.Root = r.Root()
+
+	enclosing, err := r.Resolve(path)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if len(enclosing) == 0 {
+		result.Error = fmt.Sprintf("%s belongs to no workspace", file)
+		return result
+	}
+	result.Workspace = enclosing[len(enclosing)-1]
+	result.Enclosing = enclosing
+	return result
+}
+
+// resolverCache hands out a resolver per search root, found from the
+// configuration governing each directory
+type resolverCache struct {
+	config   string // Configuration file given with --config
+	maxDepth int
+	byDir    map[string]*pipeline.Resolver
+	byRoot   map[string]*pipeline.Resolver
+}
+
+func newResolverCache(configPath string, maxDepth int) *resolverCache {
+	return &resolverCache{
+		config:   configPath,
+		maxDepth: maxDepth,
+		byDir:    make(map[string]*pipeline.Resolver),
+		byRoot:   make(map[string]*pipeline.Resolver),
+	}
+}
+
+func (c *resolverCache) get(dir string) (*pipeline.Resolver, error) {
+	if r, ok := c.byDir[dir]; ok {
+		return r, nil
+	}
+
+	cfg := config.Load(c.config, dir)
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	root, err := filepath.Abs(cfg.ConfigDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if cfg.ConfigFile == "" {
+		// Without a configuration file, search from the git work tree
+		if tree := changes.WorkTree(dir); tree != "" {
+			root = tree
+		}
+	}
+
+	r, ok := c.byRoot[root]
+	if !ok {
+		r = pipeline.NewResolver(root, cfg, pipeline.Options{MaxDepth: c.maxDepth})
+		c.byRoot[root] = r
+	}
+	c.byDir[dir] = r
+	return r, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWhich(t *testing.T) {
+	tmpDir := setupWorkspaces(t, "apps/web", "services/api")
+
+	tests := []struct {
+		name    string
+		files   []string
+		stdin   string
+		opts    whichOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "file in a workspace",
+			files: []string{"services/api/go.mod"},
+			want:  filepath.Join(tmpDir, "services", "api") + "\n",
+		},
+		{
+			name:  "missing file",
+			files: []string{filepath.Join(tmpDir, "apps", "web", "src", "deleted.ts")},
+			want:  filepath.Join(tmpDir, "apps", "web") + "\n",
+		},
+		{
+			name:  "stdin batch, relative and deduplicated",
+			stdin: "services/api/go.mod\x00apps/web/go.mod\x00services/api/main.go\x00",
+			opts:  whichOptions{stdin: true, relative: true},
+			want:  filepath.Join("services", "api") + "\n" + filepath.Join("apps", "web") + "\n",
+		},
+		{
+			name:  "files in no workspace are skipped",
+			files: []string{"README.md", "apps/web/go.mod"},
+			opts:  whichOptions{silent: true},
+			want:  filepath.Join(tmpDir, "apps", "web") + "\n",
+		},
+		{
+			name:    "no workspace",
+			files:   []string{"README.md"},
+			opts:    whichOptions{silent: true},
+			wantErr: true,
+		},
+		{
+			name:    "cd needs a single workspace",
+			files:   []string{"apps/web/go.mod", "services/api/go.mod"},
+			opts:    whichOptions{format: "cd"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.opts.format == "" {
+				tt.opts.format = "path"
+			}
+			out, err := captureStdout(t, func() error {
+				return runWhich(tt.files, strings.NewReader(tt.stdin), &tt.opts)
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runWhich() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && out != tt.want {
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Package changes lists the files changed in a git work tree and maps them
+// to the workspaces they belong to.
+package changes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// gitTimeout bounds each git command
+const gitTimeout = 10 * time.Second
+
+// WorkTree returns the nearest directory at or above dir containing .git,
+// or an empty string outside a git work tree
+func WorkTree(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Files lists the files of the work tree, relative to it, that differ from
+// the merge base of ref and HEAD, or from HEAD when ref is empty. Changes in
+// the working tree and untracked files are included, and renamed files are
+// listed under both names.
+func Files(tree, ref string) ([]string, error) {
+	base := "HEAD"
+	if ref != "" {
+		out, err := git(tree, "merge-base", ref, "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		base = strings.TrimSpace(out)
+	}
+
+	diff, err := git(tree, "diff", "--name-only", "--no-renames", "-z", base)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(tree, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, file := range strings.Split(diff+untracked, "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// Affected is the outcome of mapping changed files to workspaces
+type Affected struct {
+	// Workspaces holding changed files, or every workspace when a global
+	// file changed, in the order they were given
+	Workspaces []*workspace.Workspace `json:"workspaces"`
+	Global     []string               `json:"global"`  // Changed files matching a global pattern
+	Unowned    []string               `json:"unowned"` // Changed files in no workspace
+}
+
+// Map assigns each changed file, given as an absolute path, to the innermost
+// workspace containing it. Files outside root are ignored. A file matching
+// one of the global patterns, relative to root, affects every workspace.
+// Reported files are relative to root, with forward slashes.
+func Map(root string, files []string, workspaces []*workspace.Workspace, global []string) *Affected {
+	byPath := make(map[string]bool, len(workspaces))
+	for _, ws := range workspaces {
+		byPath[ws.Path] = true
+	}
+
+	result := &Affected{Workspaces: []*workspace.Workspace{}, Global: []string{}, Unowned: []string{}}
+	affected := make(map[string]bool)
+	for _, file := range files {
+		rel, err := filepath.Rel(root, file)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		if slices.ContainsFunc(global, func(pattern string) bool {
+			matched, _ := doublestar.Match(pattern, rel)
+			return matched
+		}) {
+			result.Global = append(result.Global, rel)
+			continue
+		}
+
+		owner := ""
+		for dir := filepath.Dir(file); len(dir) >= len(root); dir = filepath.Dir(dir) {
+			if byPath[dir] {
+				owner = dir
+				break
+			}
+			if dir == filepath.Dir(dir) {
+				break
+			}
+		}
+		if owner == "" {
+			result.Unowned = append(result.Unowned, rel)
+			continue
+		}
+		affected[owner] = true
+	}
+
+	for _, ws := range workspaces {
+		if len(result.Global) > 0 || affected[ws.Path] {
+			result.Workspaces = append(result.Workspaces, ws)
+		}
+	}
+	return result
+}
@@ -0,0 +1,146 @@
+package changes
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+func TestFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	write := func(name string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("content of "+filepath.Base(name)+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q", "-b", "main")
+	write("apps/web/index.js")
+	write("services/api/main.go")
+	write("services/billing/go.mod")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+	git("checkout", "-q", "-b", "feature")
+	git("mv", "services/api/main.go", "services/billing/main.go")
+	git("commit", "-q", "-m", "move")
+	write("apps/web/new.js")
+
+	if tree := WorkTree(filepath.Join(root, "apps", "web")); tree != root {
+		t.Errorf("WorkTree() = %q, want %q", tree, root)
+	}
+
+	tests := []struct {
+		ref     string
+		want    []string
+		wantErr bool
+	}{
+		{ref: "", want: []string{"apps/web/new.js"}},
+		{ref: "main", want: []string{"apps/web/new.js", "services/api/main.go", "services/billing/main.go"}},
+		{ref: "no-such-ref", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			files, err := Files(root, tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Files(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			}
+			slices.Sort(files)
+			if !slices.Equal(files, tt.want) {
+				t.Errorf("Files(%q) = %q, want %q", tt.ref, files, tt.want)
+			}
+		})
+	}
+}
+
+func TestMap(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "repo")
+	path := func(rel string) string { return filepath.Join(root, filepath.FromSlash(rel)) }
+	workspaces := []*workspace.Workspace{
+		{Path: path("apps/web"), Name: "web"},
+		{Path: path("services/api"), Name: "api"},
+		{Path: path("services/api/plugins/auth"), Name: "auth"},
+		{Path: path("services/billing"), Name: "billing"},
+	}
+	names := func(a *Affected) []string {
+		var out []string
+		for _, ws := range a.Workspaces {
+			out = append(out, ws.Name)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name        string
+		files       []string
+		global      []string
+		want        []string
+		wantUnowned []string
+		wantGlobal  []string
+	}{
+		{
+			name:        "innermost workspace",
+			files:       []string{"services/api/plugins/auth/token.go", "apps/web/index.js", "docs/README.md"},
+			want:        []string{"web", "auth"},
+			wantUnowned: []string{"docs/README.md"},
+		},
+		{
+			name:  "nested workspace does not affect its parent",
+			files: []string{"services/api/main.go"},
+			want:  []string{"api"},
+		},
+		{
+			name:       "global file affects every workspace",
+			files:      []string{"pnpm-lock.yaml", "apps/web/index.js"},
+			global:     []string{"pnpm-lock.yaml", "*.work"},
+			want:       []string{"web", "api", "auth", "billing"},
+			wantGlobal: []string{"pnpm-lock.yaml"},
+		},
+		{
+			name:  "files outside the root are ignored",
+			files: []string{filepath.Join(string(filepath.Separator), "other", "main.go")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := make([]string, len(tt.files))
+			for i, file := range tt.files {
+				if filepath.IsAbs(file) {
+					files[i] = file
+				} else {
+					files[i] = path(file)
+				}
+			}
+			got := Map(root, files, workspaces, tt.global)
+			if !slices.Equal(names(got), tt.want) {
+				t.Errorf("workspaces = %v, want %v", names(got), tt.want)
+			}
+			if !slices.Equal(got.Unowned, tt.wantUnowned) {
+				t.Errorf("unowned = %v, want %v", got.Unowned, tt.wantUnowned)
+			}
+			if !slices.Equal(got.Global, tt.wantGlobal) {
+				t.Errorf("global = %v, want %v", got.Global, tt.wantGlobal)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+// Package codeowners reads GitHub CODEOWNERS files and looks up the owners
+// of directories.
+package codeowners
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Locations lists where GitHub looks for the CODEOWNERS file of a
+// repository, relative to its root, in the order it does
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Rule assigns owners to the paths matching a pattern. A rule without
+// owners leaves the paths unowned.
+type Rule struct {
+	Pattern string
+	Owners  []string
+	Line    int
+}
+
+// File is a parsed CODEOWNERS file
+type File struct {
+	Path  string
+	Rules []Rule
+}
+
+// Find returns the CODEOWNERS file of the repository at root, or an empty
+// string when it has none
+func Find(root string) string {
+	for _, location := range Locations {
+		path := filepath.Join(root, filepath.FromSlash(location))
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// Load parses the CODEOWNERS file at path
+func Load(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file, err := Parse(f)
+	if err != nil {
+		return nil, err
+	}
+	file.Path = path
+	return file, nil
+}
+
+// Parse reads CODEOWNERS rules, skipping blank lines and comments
+func Parse(r io.Reader) (*File, error) {
+	file := &File{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		file.Rules = append(file.Rules, Rule{Pattern: fields[0], Owners: fields[1:], Line: line})
+	}
+	return file, scanner.Err()
+}
+
+// Owners returns the owners of the directory at rel, a slash-separated path
+// relative to the repository root, from the last rule matching it. A rule
+// matches a directory when it matches the directory itself or one of its
+// parents, or names the files directly inside it, such as "apps/web/*".
+func (f *File) Owners(rel string) []string {
+	rel = strings.Trim(rel, "/")
+	if rel == "." {
+		rel = ""
+	}
+	for i := len(f.Rules) - 1; i >= 0; i-- {
+		if f.Rules[i].matches(rel) {
+			return f.Rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// matches applies the gitignore-like rules of CODEOWNERS patterns: a
+// pattern with a leading or inner slash is relative to the repository root,
+// others match at any depth
+func (r Rule) matches(dir string) bool {
+	pattern := strings.TrimSuffix(r.Pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	if dir == "" {
+		// Only catch-all rules apply to the repository root
+		return pattern == "*" || pattern == "**"
+	}
+	if !anchored {
+		pattern = "**/" + pattern
+	}
+
+	// "docs/*" owns the files directly in docs but not those in its
+	// subdirectories, while "docs/**" owns everything below docs
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		matched, _ := doublestar.Match(prefix, dir)
+		return matched
+	}
+	pattern = strings.TrimSuffix(pattern, "/**")
+	for candidate := dir; ; {
+		if matched, _ := doublestar.Match(pattern, candidate); matched {
+			return true
+		}
+		i := strings.LastIndex(candidate, "/")
+		if i < 0 {
+			return false
+		}
+		candidate = candidate[:i]
+	}
+}
+
+// Index finds the repository of directories and the owners its CODEOWNERS
+// file assigns to them, reading each file once
+type Index struct {
+	roots map[string]string // Repository root of each directory, or ""
+	files map[string]*File  // CODEOWNERS file of each repository root, or nil
+}
+
+// NewIndex returns an empty Index
+func NewIndex() *Index {
+	return &Index{
+		roots: make(map[string]string),
+		files: make(map[string]*File),
+	}
+}
+
+// Owners returns the owners of dir in the repository containing it, or nil
+// when it has no owners or no CODEOWNERS file
+func (ix *Index) Owners(dir string) []string {
+	file := ix.File(dir)
+	if file == nil {
+		return nil
+	}
+	rel, err := filepath.Rel(ix.root(dir), dir)
+	if err != nil {
+		return nil
+	}
+	return file.Owners(filepath.ToSlash(rel))
+}
+
+// File returns the CODEOWNERS file of the repository containing dir, or nil
+// when there is none. An unreadable file counts as none.
+func (ix *Index) File(dir string) *File {
+	root := ix.root(dir)
+	if root == "" {
+		return nil
+	}
+	file, ok := ix.files[root]
+	if !ok {
+		if path := Find(root); path != "" {
+			file, _ = Load(path)
+		}
+		ix.files[root] = file
+	}
+	return file
+}
+
+// root returns the nearest directory at or above dir containing .git
+func (ix *Index) root(dir string) string {
+	if root, ok := ix.roots[dir]; ok {
+		return root
+	}
+	root := ""
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		root = dir
+	} else if parent := filepath.Dir(dir); parent != dir {
+		root = ix.root(parent)
+	}
+	ix.roots[dir] = root
+	return root
+}
@@ -0,0 +1,89 @@
+package codeowners
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFile_Owners(t *testing.T) {
+	file, err := Parse(strings.NewReader(`# Default owners
+*                     @acme/platform
+
+apps/                 @acme/frontend
+/services/billing/    @acme/payments billing@example.com
+/docs/*               @acme/docs
+/libs/**              @acme/libs
+/libs/legacy/
+*.md                  @acme/writers
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dir  string
+		want []string
+	}{
+		{".", []string{"@acme/platform"}},
+		{"tools/lint", []string{"@acme/platform"}},
+		{"apps/web", []string{"@acme/frontend"}},
+		{"packages/apps/admin", []string{"@acme/frontend"}},
+		{"services/billing", []string{"@acme/payments", "billing@example.com"}},
+		{"services/billing/worker", []string{"@acme/payments", "billing@example.com"}},
+		{"services/api", []string{"@acme/platform"}},
+		{"docs", []string{"@acme/docs"}},
+		{"docs/guides", []string{"@acme/platform"}},
+		{"libs/auth/v2", []string{"@acme/libs"}},
+		{"libs/legacy", nil},
+		{"handbook.md", []string{"@acme/writers"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			if got := file.Owners(tt.dir); !slices.Equal(got, tt.want) {
+				t.Errorf("Owners(%q) = %v, want %v", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndex(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// .github/CODEOWNERS takes precedence over the one at the root
+	write("repo/.git/HEAD", "")
+	write("repo/.github/CODEOWNERS", "/api/ @acme/api\n")
+	write("repo/CODEOWNERS", "* @acme/ignored\n")
+	write("other/.git/HEAD", "")
+	write("other/docs/CODEOWNERS", "* @acme/other\n")
+
+	ix := NewIndex()
+	tests := []struct {
+		dir  string
+		want []string
+	}{
+		{"repo/api", []string{"@acme/api"}},
+		{"repo/web", nil},
+		{"other/lib", []string{"@acme/other"}},
+		{"outside", nil},
+	}
+	for _, tt := range tests {
+		if got := ix.Owners(filepath.Join(root, tt.dir)); !slices.Equal(got, tt.want) {
+			t.Errorf("Owners(%q) = %v, want %v", tt.dir, got, tt.want)
+		}
+	}
+	if file := ix.File(filepath.Join(root, "repo", "api")); file == nil || file.Path != filepath.Join(root, "repo", ".github", "CODEOWNERS") {
+		t.Errorf("File() = %+v, want .github/CODEOWNERS", file)
+	}
+}
@@ -3,30 +3,147 @@ package config
 import (
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/goccy/go-yaml"
+	"github.com/yuya-takeyama/panama/internal/rootdir"
 )
 
+const (
+	MergeAppend  = "append"
+	MergeReplace = "replace"
+)
+
+// Config is the panama configuration. The desc tags document each key and
+// are used to generate the JSON Schema.
 type Config struct {
-	MaxDepth   int      `yaml:"max_depth"`
-	Format     string   `yaml:"format"`
-	Silent     bool     `yaml:"silent"`
-	NoCache    bool     `yaml:"no_cache"`
-	IgnoreDirs []string `yaml:"ignored_dirs"`
-	Patterns   []string `yaml:"patterns"` // Custom workspace detection patterns
-	ConfigDir  string   `yaml:"-"`        // Directory where config was found
+	Extends    []string                   `yaml:"extends" desc:"Configuration files applied before this one, as paths relative to this file or names of files in ~/.config/panama"`
+	Merge      map[string]string          `yaml:"merge" desc:"How list keys combine with extended or parent values: append (default) or replace"`
+	MaxDepth   int                        `yaml:"max_depth" desc:"Maximum depth to search for workspaces from the root directory"`
+	Format     string                     `yaml:"format" desc:"Default output format"`
+	Silent     bool                       `yaml:"silent" desc:"Suppress non-essential output"`
+	NoCache    bool                       `yaml:"no_cache" desc:"Disable caching"`
+	IgnoreDirs []string                   `yaml:"ignored_dirs" desc:"Directory names skipped entirely during the workspace search"`
+	Patterns   []string                   `yaml:"patterns" desc:"File or glob patterns marking a workspace root, in addition to .git directories"`
+	Preview    PreviewConfig              `yaml:"preview" desc:"Preview pane shown next to the interactive finder"`
+	Actions    []Action                   `yaml:"actions" desc:"Commands bound to keys in the interactive finder; not supported by peco"`
+	Finder     string                     `yaml:"finder" desc:"Fuzzy finder used by select: builtin, or an external fzf, sk or peco"`
+	FinderOpts []string                   `yaml:"finder_options" desc:"Extra command-line options passed to an external finder"`
+	Group      string                     `yaml:"group" desc:"Group workspaces in the built-in finder: none, dir for their top-level directory, or type for their package type"`
+	Sort       string                     `yaml:"sort" desc:"Order of the workspaces in the finder and list: path, name, depth, modified, commit or frecency"`
+	Matcher    string                     `yaml:"matcher" desc:"Algorithm ranking matches in the built-in finder and for a non-interactive --query, and matching the free text of --where: fuzzy, or smart for smart-case, path-segment and acronym aware scoring"`
+	Affected   AffectedConfig             `yaml:"affected" desc:"How panama affected maps git changes to workspaces"`
+	Root       RootConfig                 `yaml:"root" desc:"How panama root finds the project root"`
+	Workspaces map[string]WorkspaceConfig `yaml:"workspaces" desc:"Descriptions, tags, aliases and other metadata of the workspaces matching each glob, relative to the configuration directory; a .panama-workspace.yaml file in the workspace takes precedence"`
+	ConfigDir  string                     `yaml:"-"` // Directory where config was found
+	ConfigFile string                     `yaml:"-"` // Path of the config file that was loaded
+	Warnings   []string                   `yaml:"-"` // Problems found while loading
+
+	keys map[string]bool // Keys explicitly set by a configuration file
+}
+
+// PreviewConfig configures the preview pane of the interactive finder
+type PreviewConfig struct {
+	Disabled    bool   `yaml:"disabled" desc:"Hide the preview pane"`
+	Command     string `yaml:"command" desc:"Shell command whose output replaces the built-in preview; {} is replaced with the quoted workspace path"`
+	ReadmeLines int    `yaml:"readme_lines" desc:"Number of README lines to show (0 hides the README)"`
+	TreeDepth   int    `yaml:"tree_depth" desc:"Depth of the file tree to show (0 hides the tree)"`
+	Commits     int    `yaml:"commits" desc:"Number of recent commits touching the workspace to show (0 hides them)"`
+}
+
+// AffectedConfig configures panama affected
+type AffectedConfig struct {
+	Base   string   `yaml:"base" desc:"Git ref changes are compared against when --since is not given; empty compares against HEAD"`
+	Global []string `yaml:"global" desc:"Globs, relative to the configuration directory, of files whose changes affect every workspace, such as lock files"`
+}
+
+// RootConfig configures panama root
+type RootConfig struct {
+	Markers   []string `yaml:"markers" desc:"Files or directories marking a project root, such as .git, go.work or pnpm-workspace.yaml; panama configuration files always do"`
+	Outermost bool     `yaml:"outermost" desc:"Take the outermost marked directory instead of the nearest"`
+	Ceilings  []string `yaml:"ceilings" desc:"Absolute directories, or ~, that the search never climbs into, in addition to PANAMA_CEILING_DIRECTORIES and GIT_CEILING_DIRECTORIES"`
+}
+
+// CeilingDirs returns the ceilings with ~ expanded
+func (r RootConfig) CeilingDirs() []string {
+	dirs := make([]string, len(r.Ceilings))
+	for i, dir := range r.Ceilings {
+		dirs[i] = expandHome(dir)
+	}
+	return dirs
+}
+
+// Action is a command bound to a key in the interactive finder. An action
+// with neither run nor builtin ends the finder and reports its name.
+type Action struct {
+	Key     string `yaml:"key" desc:"Key that triggers the action, such as ctrl-e, alt-t or f5"`
+	Name    string `yaml:"name" desc:"Name shown in the finder header and reported with --print-action"`
+	Run     string `yaml:"run" desc:"Shell command run in the chosen workspace; {} is replaced with its quoted path"`
+	Return  bool   `yaml:"return" desc:"Return to the finder after running the command instead of exiting"`
+	Builtin string `yaml:"builtin" desc:"Built-in action to perform instead of a command"`
 }
 
+// Built-in actions
+const (
+	BuiltinRescan = "rescan" // Search the workspaces again
+	BuiltinDrill  = "drill"  // Choose a file or directory inside the workspace
+)
+
+// Builtins lists the valid values for an action's builtin key
+var Builtins = []string{BuiltinRescan, BuiltinDrill}
+
+// Label returns the name of the action, or its key when it has no name
+func (a Action) Label() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.Key
+}
+
+// Formats lists the valid values for the format key
+var Formats = []string{"path", "cd", "json", "nul"}
+
+// Finders lists the valid values for the finder key
+var Finders = []string{"builtin", "fzf", "sk", "peco"}
+
+// Groupings lists the valid values for the group key
+var Groupings = []string{"none", "dir", "type"}
+
+// Sorts lists the valid values for the sort key
+var Sorts = []string{"path", "name", "depth", "modified", "commit", "frecency"}
+
+// Matchers lists the valid values for the matcher key
+var Matchers = []string{"fuzzy", "smart"}
+
+// overrideKeys are the keys a nested configuration file may set for its subtree
+var overrideKeys = []string{"max_depth", "ignored_dirs", "patterns"}
+
 func DefaultConfig() *Config {
 	return &Config{
 		MaxDepth:   6,
 		Format:     "path",
 		Silent:     false,
+		Finder:     "builtin",
+		Group:      "none",
+		Sort:       "path",
+		Matcher:    "fuzzy",
 		NoCache:    false,
 		IgnoreDirs: []string{}, // No defaults - configured via init
 		Patterns:   []string{}, // No defaults - configured via init
+		Root: RootConfig{
+			Markers: []string{".git"},
+		},
+		Preview: PreviewConfig{
+			ReadmeLines: 10,
+			TreeDepth:   2,
+			Commits:     5,
+		},
 	}
 }
 
@@ -36,31 +153,26 @@ func Load(configPath, rootDir string) *Config {
 	// If config path is provided, use it directly
 	if configPath != "" {
 		if err := loadFromFile(configPath, cfg); err != nil {
-			log.Printf("Warning: failed to load config from %s: %v", configPath, err)
+			cfg.warn("failed to load config from %s: %v", configPath, err)
 		}
 		cfg.ConfigDir = filepath.Dir(configPath)
+		cfg.ConfigFile = configPath
 		return cfg
 	}
 
 	// Search for config file upward from rootDir
-	dir := rootDir
-	for {
-		for _, name := range []string{".panama.yaml", ".panama.yml"} {
-			path := filepath.Join(dir, name)
-			if _, err := os.Stat(path); err == nil {
-				if err := loadFromFile(path, cfg); err != nil {
-					log.Printf("Warning: failed to load config from %s: %v", path, err)
-				}
-				cfg.ConfigDir = dir // Store the directory where config was found
-				return cfg
-			}
-		}
-
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			break
+	dir := rootdir.Find(rootDir, rootdir.Options{
+		Match:    func(d string) bool { return FindInDir(d) != "" },
+		Ceilings: rootdir.EnvCeilings(),
+	})
+	if dir != "" {
+		path := FindInDir(dir)
+		if err := loadFromFile(path, cfg); err != nil {
+			cfg.warn("failed to load config from %s: %v", path, err)
 		}
-		dir = parent
+		cfg.ConfigDir = dir // Store the directory where config was found
+		cfg.ConfigFile = path
+		return cfg
 	}
 
 	// No config found, use rootDir as default
@@ -68,18 +180,325 @@ func Load(configPath, rootDir string) *Config {
 	return cfg
 }
 
+// LoadOverride loads a nested configuration file in dir and applies it on top
+// of parent. Only max_depth, ignored_dirs and patterns are taken from the
+// nested file. It returns nil when dir has no configuration file.
+func LoadOverride(dir string, parent *Config) (*Config, error) {
+	path := findOverrideInDir(dir)
+	if path == "" {
+		return nil, nil
+	}
+
+	cfg := parent.clone()
+	cfg.keys = nil
+	cfg.Warnings = nil
+	if err := loadLayer(path, cfg, overrideKeys, map[string]bool{}); err != nil {
+		return nil, err
+	}
+	cfg.ConfigDir = dir
+	cfg.ConfigFile = path
+	return cfg, nil
+}
+
+// warn logs a loading problem and records it in Warnings
+func (c *Config) warn(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	log.Printf("Warning: %s", msg)
+	c.Warnings = append(c.Warnings, msg)
+}
+
+// Has reports whether key was explicitly set by a loaded configuration file
+func (c *Config) Has(key string) bool {
+	return c.keys[key]
+}
+
+func (c *Config) clone() *Config {
+	cp := *c
+	cp.keys = make(map[string]bool, len(c.keys))
+	for k, v := range c.keys {
+		cp.keys[k] = v
+	}
+	return &cp
+}
+
 func loadFromFile(path string, cfg *Config) error {
-	data, err := os.ReadFile(path)
+	return loadLayer(path, cfg, nil, map[string]bool{})
+}
+
+// loadLayer applies the configuration file at path to cfg. Files listed in
+// extends are applied first, so keys in path take precedence over them.
+// When allowed is non-nil, only those keys are applied.
+func loadLayer(path string, cfg *Config, allowed []string, visiting map[string]bool) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if visiting[absPath] {
+		return fmt.Errorf("circular extends: %s", path)
+	}
+	visiting[absPath] = true
+	defer delete(visiting, absPath)
+
+	raw, err := readRaw(absPath)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return fmt.Errorf("no panama section found in %s", path)
+	}
+
+	extends, err := stringList(raw["extends"])
+	if err != nil {
+		return fmt.Errorf("extends: %w", err)
+	}
+	for _, ref := range extends {
+		basePath, err := resolveExtends(filepath.Dir(absPath), ref)
+		if err != nil {
+			return err
+		}
+		if err := loadLayer(basePath, cfg, allowed, visiting); err != nil {
+			return fmt.Errorf("%s: %w", ref, err)
+		}
+	}
+	raw["extends"] = extends
+
+	// Round-trip through YAML so every field uses the same decoding rules
+	data, err := yaml.Marshal(raw)
 	if err != nil {
 		return err
 	}
+	layer := &Config{}
+	if err := yaml.Unmarshal(data, layer); err != nil {
+		return err
+	}
 
-	ext := filepath.Ext(path)
-	switch ext {
-	case ".yaml", ".yml":
-		return yaml.Unmarshal(data, cfg)
+	for key, mode := range layer.Merge {
+		if mode != MergeAppend && mode != MergeReplace {
+			return fmt.Errorf("merge mode for %s must be one of: %s, %s", key, MergeAppend, MergeReplace)
+		}
+	}
+
+	apply(cfg, layer, raw, allowed, path)
+	return nil
+}
+
+// apply copies every key present in raw from layer into cfg. List keys are
+// appended to the existing value unless the layer's merge mode is replace.
+func apply(cfg, layer *Config, raw map[string]any, allowed []string, source string) {
+	if cfg.keys == nil {
+		cfg.keys = map[string]bool{}
+	}
+
+	dst := reflect.ValueOf(cfg).Elem()
+	src := reflect.ValueOf(layer).Elem()
+	t := dst.Type()
+
+	for _, key := range unknownKeys(t, raw, "") {
+		// Reported without logging so typos do not break existing setups
+		cfg.Warnings = append(cfg.Warnings, fmt.Sprintf("unknown key %q in %s", key, source))
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		key := yamlKey(t.Field(i))
+		if key == "" || key == "extends" || key == "merge" {
+			continue
+		}
+		if _, ok := raw[key]; !ok {
+			continue
+		}
+		if allowed != nil && !slices.Contains(allowed, key) {
+			continue
+		}
+
+		applyValue(dst.Field(i), src.Field(i), raw[key], layer.Merge[key])
+		cfg.keys[key] = true
+	}
+}
+
+// applyValue sets dst from src. Nested sections are merged key by key, so a
+// file setting preview.command keeps the other preview settings.
+func applyValue(dst, src reflect.Value, raw any, mode string) {
+	switch src.Kind() {
+	case reflect.Slice:
+		if mode != MergeReplace {
+			src = appendUnique(dst, src)
+		}
+	case reflect.Map:
+		// Entries are merged by key, so an extending file can describe
+		// more workspaces without repeating the others
+		if mode != MergeReplace && !dst.IsNil() {
+			merged := reflect.MakeMapWithSize(src.Type(), dst.Len()+src.Len())
+			for _, m := range []reflect.Value{dst, src} {
+				iter := m.MapRange()
+				for iter.Next() {
+					merged.SetMapIndex(iter.Key(), iter.Value())
+				}
+			}
+			src = merged
+		}
+	case reflect.Struct:
+		if section, ok := raw.(map[string]any); ok {
+			t := src.Type()
+			for i := 0; i < t.NumField(); i++ {
+				key := yamlKey(t.Field(i))
+				if value, ok := section[key]; ok && key != "" {
+					applyValue(dst.Field(i), src.Field(i), value, mode)
+				}
+			}
+			return
+		}
+	}
+	dst.Set(src)
+}
+
+// unknownKeys returns the keys in raw that do not correspond to a field of
+// t, including keys inside nested sections, sorted by name
+func unknownKeys(t reflect.Type, raw map[string]any, prefix string) []string {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		if key := yamlKey(t.Field(i)); key != "" {
+			fields[key] = t.Field(i)
+		}
+	}
+
+	var unknown []string
+	for _, key := range slices.Sorted(maps.Keys(raw)) {
+		field, ok := fields[key]
+		if !ok {
+			unknown = append(unknown, prefix+key)
+			continue
+		}
+		section, ok := raw[key].(map[string]any)
+		if !ok {
+			continue
+		}
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			unknown = append(unknown, unknownKeys(field.Type, section, prefix+key+".")...)
+		case field.Type.Kind() == reflect.Map && field.Type.Elem().Kind() == reflect.Struct:
+			for _, name := range slices.Sorted(maps.Keys(section)) {
+				if entry, ok := section[name].(map[string]any); ok {
+					unknown = append(unknown, unknownKeys(field.Type.Elem(), entry, prefix+key+"."+name+".")...)
+				}
+			}
+		}
+	}
+	return unknown
+}
+
+// appendUnique returns a new slice holding base followed by the elements of
+// extra that are not already present
+func appendUnique(base, extra reflect.Value) reflect.Value {
+	merged := reflect.MakeSlice(base.Type(), 0, base.Len()+extra.Len())
+	merged = reflect.AppendSlice(merged, base)
+	for i := 0; i < extra.Len(); i++ {
+		item := extra.Index(i)
+		duplicate := false
+		if item.Comparable() {
+			for j := 0; j < merged.Len(); j++ {
+				if merged.Index(j).Equal(item) {
+					duplicate = true
+					break
+				}
+			}
+		}
+		if !duplicate {
+			merged = reflect.Append(merged, item)
+		}
+	}
+	return merged
+}
+
+func yamlKey(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if tag == "-" {
+		return ""
+	}
+	return tag
+}
+
+// resolveExtends resolves an extends reference relative to dir. References
+// that do not exist there are looked up in the user configuration directory,
+// so a shared file such as "org" resolves to ~/.config/panama/org.yaml.
+func resolveExtends(dir, ref string) (string, error) {
+	if ref == "" {
+		return "", fmt.Errorf("extends entry must not be empty")
+	}
+
+	ref = expandHome(ref)
+	if filepath.IsAbs(ref) {
+		return ref, nil
+	}
+
+	local := filepath.Join(dir, ref)
+	if _, err := os.Stat(local); err == nil {
+		return local, nil
+	}
+
+	if userDir := UserConfigDir(); userDir != "" {
+		candidates := []string{filepath.Join(userDir, ref)}
+		if filepath.Ext(ref) == "" {
+			for _, ext := range extensions {
+				candidates = append(candidates, filepath.Join(userDir, ref+ext))
+			}
+		}
+		for _, path := range candidates {
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("extended config not found: %s", ref)
+}
+
+// UserConfigDir returns the directory holding user-level panama files,
+// honoring XDG_CONFIG_HOME and falling back to ~/.config/panama
+func UserConfigDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "panama")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "panama")
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+func stringList(v any) ([]string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a string, got %T", item)
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	case []string:
+		return v, nil
 	default:
-		return fmt.Errorf("unsupported config file format: %s (only .yaml and .yml are supported)", ext)
+		return nil, fmt.Errorf("expected a string or a list of strings, got %T", v)
 	}
 }
 
@@ -88,8 +507,74 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("max_depth must be at least 1")
 	}
 
-	if c.Format != "path" && c.Format != "cd" && c.Format != "json" {
-		return fmt.Errorf("format must be one of: path, cd, json")
+	if !slices.Contains(Formats, c.Format) {
+		return fmt.Errorf("format must be one of: %s", strings.Join(Formats, ", "))
+	}
+
+	if c.Finder != "" && !slices.Contains(Finders, c.Finder) {
+		return fmt.Errorf("finder must be one of: %s", strings.Join(Finders, ", "))
+	}
+	if c.Finder == "peco" && len(c.Actions) > 0 {
+		return fmt.Errorf("actions cannot be used with finder peco, which does not report key presses")
+	}
+
+	if c.Group != "" && !slices.Contains(Groupings, c.Group) {
+		return fmt.Errorf("group must be one of: %s", strings.Join(Groupings, ", "))
+	}
+
+	if c.Sort != "" && !slices.Contains(Sorts, c.Sort) {
+		return fmt.Errorf("sort must be one of: %s", strings.Join(Sorts, ", "))
+	}
+
+	if c.Matcher != "" && !slices.Contains(Matchers, c.Matcher) {
+		return fmt.Errorf("matcher must be one of: %s", strings.Join(Matchers, ", "))
+	}
+
+	for i, action := range c.Actions {
+		if action.Key == "" {
+			return fmt.Errorf("actions[%d]: key is required", i)
+		}
+		if action.Run != "" && action.Builtin != "" {
+			return fmt.Errorf("actions[%d]: run and builtin cannot be combined", i)
+		}
+		if action.Builtin != "" && !slices.Contains(Builtins, action.Builtin) {
+			return fmt.Errorf("actions[%d]: builtin must be one of: %s", i, strings.Join(Builtins, ", "))
+		}
+		if action.Return && action.Run == "" {
+			return fmt.Errorf("actions[%d]: return requires run", i)
+		}
+	}
+
+	for i, pattern := range c.Affected.Global {
+		if !doublestar.ValidatePattern(pattern) {
+			return fmt.Errorf("affected.global[%d]: invalid pattern %q", i, pattern)
+		}
+	}
+
+	for i, marker := range c.Root.Markers {
+		if marker == "" {
+			return fmt.Errorf("root.markers[%d]: must not be empty", i)
+		}
+	}
+	for i, dir := range c.Root.CeilingDirs() {
+		if !filepath.IsAbs(dir) {
+			return fmt.Errorf("root.ceilings[%d]: %s is not an absolute path", i, dir)
+		}
+	}
+
+	for _, pattern := range slices.Sorted(maps.Keys(c.Workspaces)) {
+		if !doublestar.ValidatePattern(pattern) {
+			return fmt.Errorf("workspaces: invalid pattern %q", pattern)
+		}
+		for i, alias := range c.Workspaces[pattern].Aliases {
+			if alias == "" {
+				return fmt.Errorf("workspaces[%q].aliases[%d]: must not be empty", pattern, i)
+			}
+		}
+	}
+
+	if c.Preview.ReadmeLines < 0 || c.Preview.TreeDepth < 0 || c.Preview.Commits < 0 {
+		return fmt.Errorf("preview.readme_lines, preview.tree_depth and preview.commits must not be negative")
 	}
 
 	return nil
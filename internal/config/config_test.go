@@ -1,8 +1,10 @@
 package config
 
 import (
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -66,6 +68,147 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "unknown grouping",
+			config: Config{
+				MaxDepth: 3,
+				Format:   "path",
+				Group:    "owner",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown sort order",
+			config: Config{
+				MaxDepth: 3,
+				Format:   "path",
+				Sort:     "size",
+			},
+			wantErr: true,
+		},
+		{
+			name: "smart matcher",
+			config: Config{
+				MaxDepth: 3,
+				Format:   "path",
+				Matcher:  "smart",
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown matcher",
+			config: Config{
+				MaxDepth: 3,
+				Format:   "path",
+				Matcher:  "regex",
+			},
+			wantErr: true,
+		},
+		{
+			name: "affected globals",
+			config: Config{
+				MaxDepth: 3,
+				Format:   "path",
+				Affected: AffectedConfig{Global: []string{"go.work", "**/*.lock"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid affected global",
+			config: Config{
+				MaxDepth: 3,
+				Format:   "path",
+				Affected: AffectedConfig{Global: []string{"[lock"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid workspaces glob",
+			config: Config{
+				MaxDepth:   3,
+				Format:     "path",
+				Workspaces: map[string]WorkspaceConfig{"services/[api": {Tags: []string{"backend"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty workspace alias",
+			config: Config{
+				MaxDepth:   3,
+				Format:     "path",
+				Workspaces: map[string]WorkspaceConfig{"services/api": {Aliases: []string{""}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "root markers and ceilings",
+			config: Config{
+				MaxDepth: 3,
+				Format:   "path",
+				Root:     RootConfig{Markers: []string{".git", "go.work"}, Ceilings: []string{"~", "/srv"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "relative root ceiling",
+			config: Config{
+				MaxDepth: 3,
+				Format:   "path",
+				Root:     RootConfig{Ceilings: []string{"home"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "actions",
+			config: Config{
+				MaxDepth: 3,
+				Format:   "path",
+				Actions: []Action{
+					{Key: "ctrl-e", Name: "edit", Run: "$EDITOR ."},
+					{Key: "ctrl-t", Run: "make test", Return: true},
+					{Key: "ctrl-r", Builtin: BuiltinRescan},
+					{Key: "ctrl-y", Name: "copy"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "action without key",
+			config: Config{
+				MaxDepth: 3,
+				Format:   "path",
+				Actions:  []Action{{Name: "edit", Run: "$EDITOR ."}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "action with unknown builtin",
+			config: Config{
+				MaxDepth: 3,
+				Format:   "path",
+				Actions:  []Action{{Key: "ctrl-r", Builtin: "reload"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "action returning without command",
+			config: Config{
+				MaxDepth: 3,
+				Format:   "path",
+				Actions:  []Action{{Key: "ctrl-r", Return: true}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "actions with peco",
+			config: Config{
+				MaxDepth: 3,
+				Format:   "path",
+				Finder:   "peco",
+				Actions:  []Action{{Key: "ctrl-e", Run: "$EDITOR ."}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -109,3 +252,395 @@ format: json
 		t.Errorf("expected Format to be 'json', got '%s'", cfg.Format)
 	}
 }
+
+func TestLoadFromFile_Extends(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	basePath := filepath.Join(tmpDir, "org.yaml")
+	baseContent := `
+max_depth: 4
+patterns:
+  - package.json
+  - go.mod
+ignored_dirs:
+  - node_modules
+`
+	if err := os.WriteFile(basePath, []byte(baseContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		content        string
+		wantMaxDepth   int
+		wantPatterns   []string
+		wantIgnoreDirs []string
+	}{
+		{
+			name: "lists are appended by default",
+			content: `
+extends: org.yaml
+patterns:
+  - Cargo.toml
+  - go.mod
+`,
+			wantMaxDepth:   4,
+			wantPatterns:   []string{"package.json", "go.mod", "Cargo.toml"},
+			wantIgnoreDirs: []string{"node_modules"},
+		},
+		{
+			name: "replace merge mode",
+			content: `
+extends:
+  - ./org.yaml
+merge:
+  ignored_dirs: replace
+max_depth: 2
+ignored_dirs:
+  - vendor
+`,
+			wantMaxDepth:   2,
+			wantPatterns:   []string{"package.json", "go.mod"},
+			wantIgnoreDirs: []string{"vendor"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, ".panama.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := DefaultConfig()
+			if err := loadFromFile(path, cfg); err != nil {
+				t.Fatalf("loadFromFile() error = %v", err)
+			}
+
+			if cfg.MaxDepth != tt.wantMaxDepth {
+				t.Errorf("MaxDepth = %d, want %d", cfg.MaxDepth, tt.wantMaxDepth)
+			}
+			if !slices.Equal(cfg.Patterns, tt.wantPatterns) {
+				t.Errorf("Patterns = %v, want %v", cfg.Patterns, tt.wantPatterns)
+			}
+			if !slices.Equal(cfg.IgnoreDirs, tt.wantIgnoreDirs) {
+				t.Errorf("IgnoreDirs = %v, want %v", cfg.IgnoreDirs, tt.wantIgnoreDirs)
+			}
+		})
+	}
+}
+
+func TestLoadFromFile_Workspaces(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	base := `
+workspaces:
+  "services/*":
+    tags: [backend]
+  services/api:
+    description: Old description
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "org.yaml"), []byte(base), 0644); err != nil {
+		t.Fatal(err)
+	}
+	content := `
+extends: org.yaml
+workspaces:
+  services/api:
+    description: Public API
+    aliases: [gateway]
+    colour: blue
+  "**":
+    meta:
+      team: core
+`
+	path := filepath.Join(tmpDir, ".panama.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	if err := loadFromFile(path, cfg); err != nil {
+		t.Fatalf("loadFromFile() error = %v", err)
+	}
+
+	if got := slices.Sorted(maps.Keys(cfg.Workspaces)); !slices.Equal(got, []string{"**", "services/*", "services/api"}) {
+		t.Errorf("Workspaces keys = %v", got)
+	}
+	if !slices.Contains(cfg.Warnings, `unknown key "workspaces.services/api.colour" in `+path) {
+		t.Errorf("expected a warning for the unknown key, got %v", cfg.Warnings)
+	}
+
+	// Wildcards first, so the exact path is applied last
+	matched := cfg.WorkspaceConfigs("services/api")
+	if len(matched) != 3 || matched[0].Meta["team"] != "core" || !slices.Equal(matched[1].Tags, []string{"backend"}) || matched[2].Description != "Public API" {
+		t.Errorf("WorkspaceConfigs(services/api) = %+v", matched)
+	}
+	if matched := cfg.WorkspaceConfigs("libs/log"); len(matched) != 1 {
+		t.Errorf("WorkspaceConfigs(libs/log) = %+v, want only the ** entry", matched)
+	}
+}
+
+func TestLoadWorkspaceFile(t *testing.T) {
+	dir := t.TempDir()
+	if wc, err := LoadWorkspaceFile(dir); wc != nil || err != nil {
+		t.Fatalf("LoadWorkspaceFile() without a file = %v, %v", wc, err)
+	}
+
+	path := filepath.Join(dir, WorkspaceFileName)
+	if err := os.WriteFile(path, []byte("description: Web app\ntags: [frontend]\neditor: code\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wc, err := LoadWorkspaceFile(dir)
+	if err != nil {
+		t.Fatalf("LoadWorkspaceFile() error = %v", err)
+	}
+	if wc.Description != "Web app" || !slices.Equal(wc.Tags, []string{"frontend"}) || wc.Editor != "code" {
+		t.Errorf("LoadWorkspaceFile() = %+v", wc)
+	}
+
+	if err := os.WriteFile(path, []byte("descripton: typo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadWorkspaceFile(dir); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
+
+func TestLoadFromFile_ExtendsUserConfig(t *testing.T) {
+	userDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+
+	if err := os.MkdirAll(filepath.Join(userDir, "panama"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(userDir, "panama", "org.yaml"), []byte("patterns:\n  - go.mod\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), ".panama.yaml")
+	if err := os.WriteFile(path, []byte("extends: org\npatterns:\n  - package.json\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	if err := loadFromFile(path, cfg); err != nil {
+		t.Fatalf("loadFromFile() error = %v", err)
+	}
+
+	want := []string{"go.mod", "package.json"}
+	if !slices.Equal(cfg.Patterns, want) {
+		t.Errorf("Patterns = %v, want %v", cfg.Patterns, want)
+	}
+}
+
+func TestLoadFromFile_CircularExtends(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.yaml"), []byte("extends: b.yaml\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "b.yaml"), []byte("extends: a.yaml\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	if err := loadFromFile(filepath.Join(tmpDir, "a.yaml"), cfg); err == nil {
+		t.Error("expected error for circular extends")
+	}
+}
+
+func TestLoadOverride(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	parent := DefaultConfig()
+	parent.Format = "json"
+	parent.Patterns = []string{"package.json"}
+
+	// No nested config
+	cfg, err := LoadOverride(tmpDir, parent)
+	if err != nil {
+		t.Fatalf("LoadOverride() error = %v", err)
+	}
+	if cfg != nil {
+		t.Fatalf("expected nil config when no file exists, got %+v", cfg)
+	}
+
+	content := `
+format: cd
+max_depth: 2
+patterns:
+  - Chart.yaml
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".panama.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err = LoadOverride(tmpDir, parent)
+	if err != nil {
+		t.Fatalf("LoadOverride() error = %v", err)
+	}
+
+	if cfg.Format != "json" {
+		t.Errorf("expected Format to be inherited, got '%s'", cfg.Format)
+	}
+	if cfg.MaxDepth != 2 || !cfg.Has("max_depth") {
+		t.Errorf("expected MaxDepth override of 2, got %d", cfg.MaxDepth)
+	}
+	want := []string{"package.json", "Chart.yaml"}
+	if !slices.Equal(cfg.Patterns, want) {
+		t.Errorf("Patterns = %v, want %v", cfg.Patterns, want)
+	}
+	if !slices.Equal(parent.Patterns, []string{"package.json"}) {
+		t.Errorf("parent Patterns modified: %v", parent.Patterns)
+	}
+}
+
+func TestLoad_FileFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		wantFile bool
+	}{
+		{
+			name:     "toml",
+			filename: ".panama.toml",
+			content:  "max_depth = 3\nformat = \"json\"\npatterns = [\"go.mod\"]\n",
+			wantFile: true,
+		},
+		{
+			name:     "json",
+			filename: ".panama.json",
+			content:  `{"max_depth": 3, "format": "json", "patterns": ["go.mod"]}`,
+			wantFile: true,
+		},
+		{
+			name:     "pyproject.toml section",
+			filename: "pyproject.toml",
+			content:  "[project]\nname = \"app\"\n\n[tool.panama]\nmax_depth = 3\nformat = \"json\"\npatterns = [\"go.mod\"]\n",
+			wantFile: true,
+		},
+		{
+			name:     "package.json key",
+			filename: "package.json",
+			content:  `{"name": "app", "panama": {"max_depth": 3, "format": "json", "patterns": ["go.mod"]}}`,
+			wantFile: true,
+		},
+		{
+			name:     "package.json without panama key",
+			filename: "package.json",
+			content:  `{"name": "app"}`,
+			wantFile: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			path := filepath.Join(tmpDir, tt.filename)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			// Embedded sections are only read at the repository root
+			if err := os.Mkdir(filepath.Join(tmpDir, ".git"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			subDir := filepath.Join(tmpDir, "sub")
+			if err := os.MkdirAll(subDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := Load("", subDir)
+
+			if !tt.wantFile {
+				if cfg.ConfigFile != "" {
+					t.Errorf("expected no config file, got %s", cfg.ConfigFile)
+				}
+				return
+			}
+
+			if cfg.ConfigFile != path {
+				t.Errorf("ConfigFile = %s, want %s", cfg.ConfigFile, path)
+			}
+			if cfg.MaxDepth != 3 {
+				t.Errorf("expected MaxDepth to be 3, got %d", cfg.MaxDepth)
+			}
+			if cfg.Format != "json" {
+				t.Errorf("expected Format to be 'json', got '%s'", cfg.Format)
+			}
+			if !slices.Equal(cfg.Patterns, []string{"go.mod"}) {
+				t.Errorf("Patterns = %v, want [go.mod]", cfg.Patterns)
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestFindInDir_HostFiles(t *testing.T) {
+	root := t.TempDir()
+	web := filepath.Join(root, "apps", "web")
+	src := filepath.Join(web, "src")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	rootFile := filepath.Join(root, "package.json")
+	files := map[string]string{
+		rootFile:                           `{"name": "monorepo", "panama": {"max_depth": 3}}`,
+		filepath.Join(web, "package.json"): `{"name": "web", "panama": {"max_depth": 1}}`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := FindInDir(root); got != rootFile {
+		t.Errorf("FindInDir(root) = %q, want %q", got, rootFile)
+	}
+	// A package of the monorepo does not shadow the repository configuration
+	if got := FindInDir(web); got != "" {
+		t.Errorf("FindInDir(apps/web) = %q, want none", got)
+	}
+	cfg := Load("", src)
+	if cfg.ConfigFile != rootFile || cfg.MaxDepth != 3 {
+		t.Errorf("Load() = %s with max_depth %d, want %s with 3", cfg.ConfigFile, cfg.MaxDepth, rootFile)
+	}
+
+	// Dedicated files count in any directory
+	dedicated := filepath.Join(web, ".panama.yaml")
+	if err := os.WriteFile(dedicated, []byte("max_depth: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindInDir(web); got != dedicated {
+		t.Errorf("FindInDir(apps/web) = %q, want %q", got, dedicated)
+	}
+}
+
+func TestLoad_Ceilings(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, ".panama.yaml"), []byte("max_depth: 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	home := filepath.Join(tmpDir, "home")
+	subDir := filepath.Join(home, "project")
+	if err := os.MkdirAll(subDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg := Load("", subDir); cfg.ConfigDir != tmpDir {
+		t.Errorf("ConfigDir = %s, want %s", cfg.ConfigDir, tmpDir)
+	}
+
+	// The configuration above the ceiling is out of reach
+	t.Setenv("PANAMA_CEILING_DIRECTORIES", home)
+	cfg := Load("", subDir)
+	if cfg.ConfigFile != "" || cfg.ConfigDir != subDir {
+		t.Errorf("ConfigFile = %q, ConfigDir = %s, want none and %s", cfg.ConfigFile, cfg.ConfigDir, subDir)
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"github.com/goccy/go-yaml"
+)
+
+// extensions lists the supported configuration file extensions in order of
+// precedence
+var extensions = []string{".yaml", ".yml", ".toml", ".json"}
+
+// FileNames lists the dedicated configuration file names searched for in
+// each directory
+var FileNames = []string{".panama.yaml", ".panama.yml", ".panama.toml", ".panama.json"}
+
+// hostFiles are project files that may embed a panama section. They are only
+// used when the section is present, and only at the root of a git
+// repository: the packages of a monorepo have such files too, and a section
+// in one of them must not shadow the configuration of the repository.
+var hostFiles = []string{"pyproject.toml", "package.json"}
+
+// FindInDir returns the configuration file in dir, or an empty string when
+// there is none. Dedicated files take precedence over embedded sections in
+// pyproject.toml and package.json, which count only when dir holds .git.
+func FindInDir(dir string) string {
+	if path := findOverrideInDir(dir); path != "" {
+		return path
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return ""
+	}
+
+	for _, name := range hostFiles {
+		path := filepath.Join(dir, name)
+		if raw, err := readRaw(path); err == nil && raw != nil {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// findOverrideInDir returns the dedicated configuration file in dir
+func findOverrideInDir(dir string) string {
+	for _, name := range FileNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// readRaw decodes the configuration file at path into a generic map. For
+// pyproject.toml and package.json, only the panama section is returned, and
+// a nil map means the section is absent.
+func readRaw(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]any{}
+	ext := filepath.Ext(path)
+	switch ext {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	case ".json":
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		normalizeNumbers(raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file format: %s (supported: .yaml, .yml, .toml, .json)", ext)
+	}
+
+	switch filepath.Base(path) {
+	case "pyproject.toml":
+		tool, _ := raw["tool"].(map[string]any)
+		section, _ := tool["panama"].(map[string]any)
+		return section, nil
+	case "package.json":
+		section, _ := raw["panama"].(map[string]any)
+		return section, nil
+	}
+
+	if raw == nil {
+		raw = map[string]any{}
+	}
+	return raw, nil
+}
+
+// normalizeNumbers converts integral JSON numbers to int64 so they decode
+// into integer fields the same way YAML and TOML numbers do
+func normalizeNumbers(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = normalizeNumbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeNumbers(item)
+		}
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < math.MaxInt64 {
+			return int64(v)
+		}
+	}
+	return v
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// SchemaID is the URL of the schema published in this repository
+const SchemaID = "https://raw.githubusercontent.com/yuya-takeyama/panama/main/schema/config.schema.json"
+
+// SchemaFileName is the name init uses for the locally written schema
+const SchemaFileName = ".panama.schema.json"
+
+// schemaOverrides holds constraints that cannot be derived from Go types
+var schemaOverrides = map[string]map[string]any{
+	"extends": {
+		"oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	},
+	"merge": {
+		"type":          "object",
+		"propertyNames": map[string]any{"enum": listKeys()},
+		"additionalProperties": map[string]any{
+			"type": "string",
+			"enum": []string{MergeAppend, MergeReplace},
+		},
+	},
+	"max_depth": {"minimum": 1},
+	"format":    {"enum": Formats},
+	"finder":    {"enum": Finders},
+	"group":     {"enum": Groupings},
+	"sort":      {"enum": Sorts},
+	"matcher":   {"enum": Matchers},
+}
+
+// Schema returns a JSON Schema describing the configuration file format
+func Schema() map[string]any {
+	schema := objectSchema(reflect.TypeOf(Config{}), reflect.ValueOf(*DefaultConfig()), schemaOverrides)
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["$id"] = SchemaID
+	schema["title"] = "panama configuration"
+	return schema
+}
+
+// SchemaJSON returns the schema as indented JSON
+func SchemaJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(Schema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func objectSchema(t reflect.Type, defaults reflect.Value, overrides map[string]map[string]any) map[string]any {
+	properties := map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := yamlKey(field)
+		if key == "" {
+			continue
+		}
+
+		var prop map[string]any
+		if field.Type.Kind() == reflect.Struct && defaults.IsValid() {
+			// Nested sections carry their own defaults
+			prop = objectSchema(field.Type, defaults.Field(i), nil)
+		} else {
+			prop = typeSchema(field.Type)
+		}
+		if desc := field.Tag.Get("desc"); desc != "" {
+			prop["description"] = desc
+		}
+		if defaults.IsValid() && field.Type.Kind() != reflect.Struct {
+			if value := defaults.Field(i); !value.IsZero() {
+				prop["default"] = value.Interface()
+			}
+		}
+		for k, v := range overrides[key] {
+			if k == "oneOf" {
+				delete(prop, "type")
+				delete(prop, "items")
+			}
+			prop[k] = v
+		}
+		properties[key] = prop
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+func typeSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		return objectSchema(t, reflect.Value{}, nil)
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	default:
+		return map[string]any{}
+	}
+}
+
+// listKeys returns the keys whose values are lists or maps, or sections
+// holding lists, and can be merged
+func listKeys() []string {
+	var keys []string
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		key := yamlKey(t.Field(i))
+		if key != "" && key != "extends" && key != "merge" && hasList(t.Field(i).Type) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func hasList(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Map:
+		return true
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if yamlKey(t.Field(i)) != "" && t.Field(i).Type.Kind() == reflect.Slice {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	schema := Schema()
+
+	properties, ok := schema["properties"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected properties in schema, got %v", schema)
+	}
+
+	for _, key := range []string{"extends", "merge", "max_depth", "format", "ignored_dirs", "patterns"} {
+		prop, ok := properties[key].(map[string]any)
+		if !ok {
+			t.Errorf("expected property %s in schema", key)
+			continue
+		}
+		if prop["description"] == "" || prop["description"] == nil {
+			t.Errorf("expected description for %s", key)
+		}
+	}
+
+	format := properties["format"].(map[string]any)
+	if format["default"] != "path" {
+		t.Errorf("expected format default to be 'path', got %v", format["default"])
+	}
+	if enum, ok := format["enum"].([]string); !ok || len(enum) != len(Formats) {
+		t.Errorf("expected format enum %v, got %v", Formats, format["enum"])
+	}
+
+	maxDepth := properties["max_depth"].(map[string]any)
+	if maxDepth["default"] != 6 {
+		t.Errorf("expected max_depth default to be 6, got %v", maxDepth["default"])
+	}
+}
+
+func TestSchemaJSON_UpToDate(t *testing.T) {
+	want, err := SchemaJSON()
+	if err != nil {
+		t.Fatalf("SchemaJSON() error = %v", err)
+	}
+	if !json.Valid(want) {
+		t.Fatalf("SchemaJSON() returned invalid JSON")
+	}
+
+	got, err := os.ReadFile(filepath.Join("..", "..", "schema", "config.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("schema/config.schema.json is out of date; run: go run ./cmd/panama config schema -o schema/config.schema.json")
+	}
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/goccy/go-yaml"
+)
+
+// WorkspaceFileName is the file describing the workspace it is in
+const WorkspaceFileName = ".panama-workspace.yaml"
+
+// WorkspaceConfig describes workspaces beyond what their manifests say. It
+// is read from the workspaces section and from .panama-workspace.yaml.
+type WorkspaceConfig struct {
+	Description string            `yaml:"description" desc:"Description shown in the finder and preview instead of the package type"`
+	Tags        []string          `yaml:"tags" desc:"Labels matched by tag: queries and list --tag"`
+	Aliases     []string          `yaml:"aliases" desc:"Other names the workspace is found by in the finder, name: queries and commands taking a workspace"`
+	Owners      []string          `yaml:"owners" desc:"Owners added to those CODEOWNERS assigns"`
+	Editor      string            `yaml:"editor" desc:"Editor command for the workspace, passed to actions as PANAMA_EDITOR"`
+	Meta        map[string]string `yaml:"meta" desc:"Custom key/value pairs shown in the preview and JSON output"`
+}
+
+// LoadWorkspaceFile reads the .panama-workspace.yaml file in dir. It
+// returns nil when dir has none.
+func LoadWorkspaceFile(dir string) (*WorkspaceConfig, error) {
+	path := filepath.Join(dir, WorkspaceFileName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	wc := &WorkspaceConfig{}
+	if err := yaml.UnmarshalWithOptions(data, wc, yaml.Strict()); err != nil {
+		return nil, fmt.Errorf("%s: %w", WorkspaceFileName, err)
+	}
+	return wc, nil
+}
+
+// WorkspaceConfigs returns the entries of the workspaces section whose glob
+// matches rel, the slash-separated path of a workspace relative to the
+// configuration directory. Globs with wildcards come first, then exact
+// paths, each in sorted order, so that more specific entries are applied
+// later.
+func (c *Config) WorkspaceConfigs(rel string) []WorkspaceConfig {
+	patterns := make([]string, 0, len(c.Workspaces))
+	for pattern := range c.Workspaces {
+		patterns = append(patterns, pattern)
+	}
+	slices.SortFunc(patterns, func(a, b string) int {
+		if wa, wb := hasWildcard(a), hasWildcard(b); wa != wb {
+			if wa {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+
+	var matched []WorkspaceConfig
+	for _, pattern := range patterns {
+		if ok, _ := doublestar.Match(strings.Trim(pattern, "/"), rel); ok {
+			matched = append(matched, c.Workspaces[pattern])
+		}
+	}
+	return matched
+}
+
+func hasWildcard(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[{")
+}
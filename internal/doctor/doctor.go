@@ -0,0 +1,296 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/pipeline"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+type Severity string
+
+const (
+	SeverityOK      Severity = "ok"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Finding is a single result of a check
+type Finding struct {
+	Check      string   `json:"check"`
+	Severity   Severity `json:"severity"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion,omitempty"`
+}
+
+type Options struct {
+	MaxDepth         int
+	LargeSubtreeDirs int           // Directory count above which a subtree is reported as large
+	SlowDir          time.Duration // Time above which a subtree is reported as slow
+	Limit            int           // Maximum number of findings per check
+}
+
+const (
+	DefaultLargeSubtreeDirs = 2000
+	DefaultSlowDir          = 200 * time.Millisecond
+	DefaultLimit            = 5
+)
+
+// Report is the outcome of running all checks
+type Report struct {
+	Root     string    `json:"root"`
+	Findings []Finding `json:"findings"`
+}
+
+// Problems returns the number of warnings and errors found
+func (r *Report) Problems() int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity != SeverityOK {
+			n++
+		}
+	}
+	return n
+}
+
+// Errors returns the number of errors found, leaving out warnings
+func (r *Report) Errors() int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == SeverityError {
+			n++
+		}
+	}
+	return n
+}
+
+// Run checks the configuration and walks rootDir looking for problems that
+// make searches slow or incomplete
+func Run(rootDir string, cfg *config.Config, opts Options) (*Report, error) {
+	if opts.LargeSubtreeDirs <= 0 {
+		opts.LargeSubtreeDirs = DefaultLargeSubtreeDirs
+	}
+	if opts.SlowDir <= 0 {
+		opts.SlowDir = DefaultSlowDir
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultLimit
+	}
+
+	report := &Report{Root: rootDir}
+	report.Findings = append(report.Findings, checkConfig(cfg)...)
+
+	result, err := pipeline.Collect(rootDir, cfg, pipeline.Options{MaxDepth: opts.MaxDepth, Stats: true})
+	if err != nil {
+		return nil, err
+	}
+	// Workspace files are otherwise only read when used; check them all
+	result.Describe(pipeline.NewDescriber(cfg), workspace.DetailMetadata)
+
+	report.Findings = append(report.Findings, Finding{
+		Check:    "walk",
+		Severity: SeverityOK,
+		Message: fmt.Sprintf("searched %d directories in %s and found %d workspaces",
+			result.Visited, result.Elapsed.Round(time.Millisecond), len(result.Workspaces)),
+	})
+	report.Findings = append(report.Findings, checkWalkErrors(rootDir, result, opts)...)
+
+	subtrees := aggregate(rootDir, result.Stats)
+	report.Findings = append(report.Findings, checkLargeSubtrees(subtrees, opts)...)
+	report.Findings = append(report.Findings, checkSlowSubtrees(subtrees, opts)...)
+
+	return report, nil
+}
+
+func checkConfig(cfg *config.Config) []Finding {
+	var findings []Finding
+
+	if cfg.ConfigFile == "" {
+		findings = append(findings, Finding{
+			Check:      "config",
+			Severity:   SeverityWarning,
+			Message:    "no configuration file found; only .git directories are detected",
+			Suggestion: "run `panama init` to create .panama.yaml",
+		})
+	} else {
+		findings = append(findings, Finding{
+			Check:    "config",
+			Severity: SeverityOK,
+			Message:  "using " + cfg.ConfigFile,
+		})
+	}
+
+	// Loading skips what it warns about; only invalid values are errors
+	for _, warning := range cfg.Warnings {
+		findings = append(findings, Finding{
+			Check:      "config",
+			Severity:   SeverityWarning,
+			Message:    warning,
+			Suggestion: "compare the file against `panama config schema`",
+		})
+	}
+
+	if err := cfg.Validate(); err != nil {
+		suggestion := "fix the invalid value"
+		if cfg.ConfigFile != "" {
+			suggestion = "fix the value in " + cfg.ConfigFile
+		}
+		findings = append(findings, Finding{
+			Check:      "config",
+			Severity:   SeverityError,
+			Message:    "invalid configuration: " + err.Error(),
+			Suggestion: suggestion,
+		})
+	}
+
+	if cfg.ConfigFile != "" && len(cfg.Patterns) == 0 {
+		findings = append(findings, Finding{
+			Check:      "config",
+			Severity:   SeverityWarning,
+			Message:    "no patterns configured; only .git directories are detected",
+			Suggestion: "add marker files such as package.json or go.mod to patterns, or run `panama init --dry-run` for a proposal",
+		})
+	}
+
+	return findings
+}
+
+func checkWalkErrors(rootDir string, result *pipeline.Result, opts Options) []Finding {
+	var findings []Finding
+
+	for i, walkErr := range result.Errors {
+		if i == opts.Limit {
+			findings = append(findings, Finding{
+				Check:    "walk",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%d more walk errors not shown", len(result.Errors)-opts.Limit),
+			})
+			break
+		}
+
+		rel := relativeTo(rootDir, walkErr.Path)
+		finding := Finding{
+			Check:    "walk",
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("%s: %v", rel, walkErr.Err),
+		}
+		switch {
+		case errors.Is(walkErr.Err, fs.ErrPermission):
+			finding.Suggestion = fmt.Sprintf("add %q to ignored_dirs or fix its permissions", filepath.Base(walkErr.Path))
+		case errors.Is(walkErr.Err, pipeline.ErrBrokenSymlink):
+			finding.Severity = SeverityWarning
+			finding.Suggestion = "remove the symlink or restore its target"
+		case errors.Is(walkErr.Err, pipeline.ErrNestedConfig):
+			finding.Suggestion = "fix the nested configuration file; it is ignored until then"
+		case errors.Is(walkErr.Err, pipeline.ErrWorkspaceFile):
+			finding.Suggestion = "fix the workspace file; the workspace is listed without it until then"
+		default:
+			finding.Suggestion = fmt.Sprintf("check that %s is readable", rel)
+		}
+		findings = append(findings, finding)
+	}
+
+	return findings
+}
+
+// subtree aggregates the directories below a top-level directory
+type subtree struct {
+	path    string
+	dirs    int
+	elapsed time.Duration
+}
+
+// aggregate groups directory stats by the first path element below rootDir
+func aggregate(rootDir string, stats []pipeline.DirStat) []*subtree {
+	byPath := map[string]*subtree{}
+	for _, stat := range stats {
+		rel, err := filepath.Rel(rootDir, stat.Path)
+		if err != nil || rel == "." {
+			continue
+		}
+		top := strings.SplitN(rel, string(filepath.Separator), 2)[0]
+		st, ok := byPath[top]
+		if !ok {
+			st = &subtree{path: top}
+			byPath[top] = st
+		}
+		st.dirs++
+		st.elapsed += stat.Elapsed
+	}
+
+	subtrees := make([]*subtree, 0, len(byPath))
+	for _, st := range byPath {
+		subtrees = append(subtrees, st)
+	}
+	sort.Slice(subtrees, func(i, j int) bool {
+		return subtrees[i].path < subtrees[j].path
+	})
+	return subtrees
+}
+
+func checkLargeSubtrees(subtrees []*subtree, opts Options) []Finding {
+	large := make([]*subtree, 0)
+	for _, st := range subtrees {
+		if st.dirs > opts.LargeSubtreeDirs {
+			large = append(large, st)
+		}
+	}
+	sort.SliceStable(large, func(i, j int) bool {
+		return large[i].dirs > large[j].dirs
+	})
+
+	var findings []Finding
+	for i, st := range large {
+		if i == opts.Limit {
+			break
+		}
+		findings = append(findings, Finding{
+			Check:      "size",
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("%s: %d directories searched", st.path, st.dirs),
+			Suggestion: fmt.Sprintf("if %s holds no workspaces, add %q to ignored_dirs", st.path, st.path),
+		})
+	}
+	return findings
+}
+
+func checkSlowSubtrees(subtrees []*subtree, opts Options) []Finding {
+	slow := make([]*subtree, 0)
+	for _, st := range subtrees {
+		if st.elapsed > opts.SlowDir {
+			slow = append(slow, st)
+		}
+	}
+	sort.SliceStable(slow, func(i, j int) bool {
+		return slow[i].elapsed > slow[j].elapsed
+	})
+
+	var findings []Finding
+	for i, st := range slow {
+		if i == opts.Limit {
+			break
+		}
+		findings = append(findings, Finding{
+			Check:      "timing",
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("%s: %s spent in %d directories", st.path, st.elapsed.Round(time.Millisecond), st.dirs),
+			Suggestion: fmt.Sprintf("add %q (or slow directories inside it) to ignored_dirs, or lower max_depth", st.path),
+		})
+	}
+	return findings
+}
+
+func relativeTo(base, path string) string {
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,112 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yuya-takeyama/panama/internal/config"
+)
+
+func TestRun(t *testing.T) {
+	root := t.TempDir()
+	configPath := filepath.Join(root, ".panama.yaml")
+	if err := os.WriteFile(configPath, []byte("patterns:\n  - go.mod\npatern: typo\nmatcher: exact\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := os.MkdirAll(filepath.Join(root, "assets", fmt.Sprintf("dir%d", i)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "svc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "svc", "go.mod"), []byte("module svc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" {
+		if err := os.Symlink(filepath.Join(root, "missing"), filepath.Join(root, "dangling")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := config.Load("", root)
+	report, err := Run(root, cfg, Options{LargeSubtreeDirs: 3})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := []struct {
+		check    string
+		severity Severity
+		message  string
+	}{
+		{"config", SeverityOK, "using " + configPath},
+		{"config", SeverityWarning, `unknown key "patern"`},
+		{"config", SeverityError, "invalid configuration: matcher must be one of"},
+		{"walk", SeverityOK, "found 1 workspaces"},
+		{"size", SeverityWarning, "assets: 6 directories searched"},
+	}
+	if runtime.GOOS != "windows" {
+		want = append(want, struct {
+			check    string
+			severity Severity
+			message  string
+		}{"walk", SeverityWarning, "dangling: broken symlink"})
+	}
+
+	for _, w := range want {
+		found := false
+		for _, f := range report.Findings {
+			if f.Check == w.check && f.Severity == w.severity && strings.Contains(f.Message, w.message) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("expected %s finding %q (%s), got %+v", w.check, w.message, w.severity, report.Findings)
+		}
+	}
+
+	if report.Problems() == 0 || report.Errors() != 1 {
+		t.Errorf("expected problems with one error, got %d errors", report.Errors())
+	}
+}
+
+func TestRun_WithoutConfig(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.Load("", root)
+	cfg.Matcher = "exact"
+
+	report, err := Run(root, cfg, Options{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if report.Problems() != 2 || report.Errors() != 1 {
+		t.Errorf("expected a warning and an error, got %+v", report.Findings)
+	}
+	for _, f := range report.Findings {
+		if f.Severity == SeverityError && f.Suggestion != "fix the invalid value" {
+			t.Errorf("suggestion = %q without a configuration file", f.Suggestion)
+		}
+	}
+}
+
+func TestRun_Healthy(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".panama.yaml"), []byte("patterns:\n  - go.mod\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Run(root, config.Load("", root), Options{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if n := report.Problems(); n != 0 {
+		t.Errorf("expected no problems, got %d: %+v", n, report.Findings)
+	}
+}
@@ -0,0 +1,161 @@
+// Package drill lists the files and directories inside a workspace for the
+// second stage of select.
+package drill
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Kind selects the entries to list
+type Kind string
+
+const (
+	KindAll   Kind = "all"
+	KindDirs  Kind = "dirs"
+	KindFiles Kind = "files"
+)
+
+// Kinds lists the valid kinds
+var Kinds = []Kind{KindAll, KindDirs, KindFiles}
+
+// ParseKind converts a --drill value to a Kind
+func ParseKind(s string) (Kind, error) {
+	if kind := Kind(s); slices.Contains(Kinds, kind) {
+		return kind, nil
+	}
+	return "", fmt.Errorf("invalid drill kind: %s (must be all, dirs or files)", s)
+}
+
+// Entry is a file or directory inside a workspace
+type Entry struct {
+	Path  string // Slash-separated path relative to the workspace; "." for the workspace itself
+	IsDir bool
+}
+
+// gitTimeout bounds the git ls-files call
+const gitTimeout = 5 * time.Second
+
+// List returns the entries below dir in lexical order, starting with the
+// workspace itself when directories are listed. Directories matching
+// ignoreDirs and .git are skipped. Inside a git work tree, files ignored by
+// git are skipped as well.
+func List(dir string, ignoreDirs []string, kind Kind) ([]Entry, error) {
+	patterns := make([]string, 0, len(ignoreDirs)+1)
+	patterns = append(patterns, "**/.git")
+	for _, d := range ignoreDirs {
+		patterns = append(patterns, "**/"+d)
+	}
+	ignored := func(rel string) bool {
+		for _, pattern := range patterns {
+			if matched, _ := doublestar.Match(pattern, rel); matched {
+				return true
+			}
+		}
+		return false
+	}
+
+	files, err := gitFiles(dir)
+	if err != nil {
+		files, err = walkFiles(dir, ignored)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Directories are derived from the files, so directories holding only
+	// ignored files are left out
+	dirs := map[string]bool{}
+	var entries []Entry
+	for _, file := range files {
+		if hasIgnoredParent(file, ignored) {
+			continue
+		}
+		if kind != KindFiles {
+			for parent := path.Dir(file); parent != "." && !dirs[parent]; parent = path.Dir(parent) {
+				dirs[parent] = true
+			}
+		}
+		if kind != KindDirs {
+			entries = append(entries, Entry{Path: file})
+		}
+	}
+	for d := range dirs {
+		entries = append(entries, Entry{Path: d, IsDir: true})
+	}
+
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	if kind != KindFiles {
+		entries = slices.Insert(entries, 0, Entry{Path: ".", IsDir: true})
+	}
+	return entries, nil
+}
+
+func hasIgnoredParent(file string, ignored func(string) bool) bool {
+	for parent := path.Dir(file); parent != "."; parent = path.Dir(parent) {
+		if ignored(parent) {
+			return true
+		}
+	}
+	return false
+}
+
+// gitFiles lists the tracked and untracked files that git does not ignore
+func gitFiles(dir string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "-C", dir, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, file := range bytes.Split(out, []byte{0}) {
+		if len(file) > 0 {
+			files = append(files, string(file))
+		}
+	}
+	return files, nil
+}
+
+// walkFiles lists the files below dir, skipping ignored directories
+func walkFiles(dir string, ignored func(string) bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable entries
+			if d != nil && d.IsDir() && p != dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if ignored(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		files = append(files, rel)
+		return nil
+	})
+	return files, err
+}
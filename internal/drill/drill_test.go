@@ -0,0 +1,98 @@
+package drill
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, name := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(""), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func paths(entries []Entry) []string {
+	result := make([]string, len(entries))
+	for i, e := range entries {
+		result[i] = e.Path
+		if e.IsDir && e.Path != "." {
+			result[i] += "/"
+		}
+	}
+	return result
+}
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir,
+		"go.mod",
+		"internal/handlers/user.go",
+		"cmd/api/main.go",
+		"node_modules/pkg/index.js",
+		".git/HEAD",
+	)
+
+	tests := []struct {
+		kind Kind
+		want []string
+	}{
+		{KindAll, []string{".", "cmd/", "cmd/api/", "cmd/api/main.go", "go.mod", "internal/", "internal/handlers/", "internal/handlers/user.go"}},
+		{KindDirs, []string{".", "cmd/", "cmd/api/", "internal/", "internal/handlers/"}},
+		{KindFiles, []string{"cmd/api/main.go", "go.mod", "internal/handlers/user.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			entries, err := List(dir, []string{"node_modules"}, tt.kind)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if got := paths(entries); !slices.Equal(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestList_GitIgnore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	writeFiles(t, dir, "main.go", "tmp/cache.bin", "docs/README.md")
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("tmp/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := List(dir, nil, KindAll)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	want := []string{".", ".gitignore", "docs/", "docs/README.md", "main.go"}
+	if got := paths(entries); !slices.Equal(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	if kind, err := ParseKind("dirs"); err != nil || kind != KindDirs {
+		t.Errorf("ParseKind(dirs) = %v, %v", kind, err)
+	}
+	if _, err := ParseKind("links"); err == nil {
+		t.Error("expected an error for an unknown kind")
+	}
+}
@@ -0,0 +1,247 @@
+package graph
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// Format is an output format of Export
+type Format string
+
+const (
+	FormatDOT     Format = "dot"     // Graphviz
+	FormatMermaid Format = "mermaid" // Mermaid flowchart, as rendered by GitHub
+	FormatJSON    Format = "json"
+)
+
+// Formats lists the formats Export writes
+var Formats = []Format{FormatDOT, FormatMermaid, FormatJSON}
+
+// Mark highlights a workspace in an exported graph
+type Mark string
+
+const (
+	MarkAffected Mark = "affected" // Changed itself
+	MarkImpacted Mark = "impacted" // Depends on a changed workspace
+)
+
+// ExportOptions configures Export
+type ExportOptions struct {
+	Root  string                        // Workspaces are identified by their path relative to Root
+	Group string                        // Cluster workspaces by "dir" or "type"; anything else draws no clusters
+	Marks map[*workspace.Workspace]Mark // Highlighted workspaces
+}
+
+// exportNode is a workspace in an exported graph
+type exportNode struct {
+	ID    string `json:"id"` // Path relative to the root, with slashes
+	Name  string `json:"name"`
+	Type  string `json:"type,omitempty"`
+	Group string `json:"group,omitempty"`
+	Mark  Mark   `json:"mark,omitempty"`
+}
+
+type exportEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	File string `json:"file"`
+}
+
+// Export writes the graph in the format. The output only depends on the
+// workspaces relative to the root and their dependencies, so that it can
+// be committed and compared.
+func Export(w io.Writer, g *Graph, format Format, opts ExportOptions) error {
+	nodes := make([]*exportNode, len(g.nodes))
+	byWorkspace := make(map[*workspace.Workspace]*exportNode, len(g.nodes))
+	for i, ws := range g.nodes {
+		nodes[i] = &exportNode{
+			ID:    filepath.ToSlash(ws.RelativePath(opts.Root)),
+			Name:  ws.Name,
+			Type:  ws.Type,
+			Mark:  opts.Marks[ws],
+			Group: group(ws, opts),
+		}
+		byWorkspace[ws] = nodes[i]
+	}
+	slices.SortFunc(nodes, func(a, b *exportNode) int { return strings.Compare(a.ID, b.ID) })
+
+	edges := make([]exportEdge, len(g.edges))
+	for i, e := range g.edges {
+		edges[i] = exportEdge{From: byWorkspace[e.From].ID, To: byWorkspace[e.To].ID, File: e.File}
+	}
+	slices.SortFunc(edges, func(a, b exportEdge) int {
+		return cmp.Or(strings.Compare(a.From, b.From), strings.Compare(a.To, b.To))
+	})
+
+	switch format {
+	case FormatDOT:
+		return writeDOT(w, nodes, edges)
+	case FormatMermaid:
+		return writeMermaid(w, nodes, edges)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Nodes []*exportNode `json:"nodes"`
+			Edges []exportEdge  `json:"edges"`
+		}{nodes, edges})
+	}
+	return fmt.Errorf("unknown graph format: %s", format)
+}
+
+// group returns the cluster of ws: its top-level directory, leaving out
+// workspaces at the top level, or its package type
+func group(ws *workspace.Workspace, opts ExportOptions) string {
+	switch opts.Group {
+	case "dir":
+		dir, _, nested := strings.Cut(filepath.ToSlash(ws.RelativePath(opts.Root)), "/")
+		if nested {
+			return dir
+		}
+	case "type":
+		return cmp.Or(ws.Type, "other")
+	}
+	return ""
+}
+
+var unsafeKey = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// keys derives Mermaid identifiers from paths and names, numbering those
+// that would collide
+type keys map[string]bool
+
+func (used keys) key(prefix, s string) string {
+	base := prefix + strings.Trim(unsafeKey.ReplaceAllString(s, "_"), "_")
+	key := base
+	for i := 2; used[key]; i++ {
+		key = fmt.Sprintf("%s_%d", base, i)
+	}
+	used[key] = true
+	return key
+}
+
+// clusters splits the nodes by group in group order, the nodes in no group
+// first
+func clusters(nodes []*exportNode) [][]*exportNode {
+	byGroup := make(map[string][]*exportNode)
+	var names []string
+	for _, n := range nodes {
+		if _, ok := byGroup[n.Group]; !ok {
+			names = append(names, n.Group)
+		}
+		byGroup[n.Group] = append(byGroup[n.Group], n)
+	}
+	slices.Sort(names)
+	result := make([][]*exportNode, len(names))
+	for i, name := range names {
+		result[i] = byGroup[name]
+	}
+	return result
+}
+
+func label(n *exportNode) string {
+	if n.ID == "." {
+		return n.Name
+	}
+	return n.ID
+}
+
+// markColors are the fill colors of highlighted workspaces
+var markColors = map[Mark]string{
+	MarkAffected: "#f4a261",
+	MarkImpacted: "#ffe8a3",
+}
+
+func writeDOT(w io.Writer, nodes []*exportNode, edges []exportEdge) error {
+	var b strings.Builder
+	b.WriteString("digraph workspaces {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, cluster := range clusters(nodes) {
+		indent := "  "
+		if name := cluster[0].Group; name != "" {
+			fmt.Fprintf(&b, "\n  subgraph %s {\n", dotQuote("cluster_"+name))
+			fmt.Fprintf(&b, "    label=%s;\n", dotQuote(name))
+			indent = "    "
+		}
+		for _, n := range cluster {
+			style := ""
+			if color, ok := markColors[n.Mark]; ok {
+				style = fmt.Sprintf(", style=filled, fillcolor=%s", dotQuote(color))
+			}
+			fmt.Fprintf(&b, "%s%s [label=%s%s];\n", indent, dotQuote(n.ID), dotQuote(label(n)), style)
+		}
+		if cluster[0].Group != "" {
+			b.WriteString("  }\n")
+		}
+	}
+	if len(edges) > 0 {
+		b.WriteString("\n")
+	}
+	for _, e := range edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(e.From), dotQuote(e.To))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func writeMermaid(w io.Writer, nodes []*exportNode, edges []exportEdge) error {
+	used := make(keys)
+	nodeKeys := make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		nodeKeys[n.ID] = used.key("ws_", n.ID)
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, cluster := range clusters(nodes) {
+		indent := "  "
+		if name := cluster[0].Group; name != "" {
+			fmt.Fprintf(&b, "  subgraph %s [%s]\n", used.key("group_", name), mermaidQuote(name))
+			indent = "    "
+		}
+		for _, n := range cluster {
+			fmt.Fprintf(&b, "%s%s[%s]\n", indent, nodeKeys[n.ID], mermaidQuote(label(n)))
+		}
+		if cluster[0].Group != "" {
+			b.WriteString("  end\n")
+		}
+	}
+	for _, e := range edges {
+		fmt.Fprintf(&b, "  %s --> %s\n", nodeKeys[e.From], nodeKeys[e.To])
+	}
+
+	for _, mark := range []Mark{MarkAffected, MarkImpacted} {
+		var marked []string
+		for _, n := range nodes {
+			if n.Mark == mark {
+				marked = append(marked, nodeKeys[n.ID])
+			}
+		}
+		if len(marked) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "  classDef %s fill:%s\n", mark, markColors[mark])
+		fmt.Fprintf(&b, "  class %s %s\n", strings.Join(marked, ","), mark)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
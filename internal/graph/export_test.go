@@ -0,0 +1,150 @@
+package graph
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+func TestExport(t *testing.T) {
+	g, ws := setupGraph(t, map[string]string{
+		"apps/web/package.json":       `{"name": "web", "dependencies": {"ui": "workspace:*"}}`,
+		"packages/ui/package.json":    `{"name": "ui", "dependencies": {"utils": "workspace:*"}}`,
+		"packages/utils/package.json": `{"name": "utils"}`,
+		"tools/package.json":          `{"name": "tools"}`,
+	})
+	for _, w := range g.Workspaces() {
+		w.Type = "node"
+	}
+	root := filepath.Dir(filepath.Dir(ws["apps/web"].Path))
+	opts := ExportOptions{
+		Root:  root,
+		Group: "dir",
+		Marks: map[*workspace.Workspace]Mark{ws["packages/utils"]: MarkAffected, ws["packages/ui"]: MarkImpacted},
+	}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{
+			format: FormatDOT,
+			want: `digraph workspaces {
+  rankdir=LR;
+  node [shape=box];
+  "tools" [label="tools"];
+
+  subgraph "cluster_apps" {
+    label="apps";
+    "apps/web" [label="apps/web"];
+  }
+
+  subgraph "cluster_packages" {
+    label="packages";
+    "packages/ui" [label="packages/ui", style=filled, fillcolor="#ffe8a3"];
+    "packages/utils" [label="packages/utils", style=filled, fillcolor="#f4a261"];
+  }
+
+  "apps/web" -> "packages/ui";
+  "packages/ui" -> "packages/utils";
+}
+`,
+		},
+		{
+			format: FormatMermaid,
+			want: `flowchart LR
+  ws_tools["tools"]
+  subgraph group_apps ["apps"]
+    ws_apps_web["apps/web"]
+  end
+  subgraph group_packages ["packages"]
+    ws_packages_ui["packages/ui"]
+    ws_packages_utils["packages/utils"]
+  end
+  ws_apps_web --> ws_packages_ui
+  ws_packages_ui --> ws_packages_utils
+  classDef affected fill:#f4a261
+  class ws_packages_utils affected
+  classDef impacted fill:#ffe8a3
+  class ws_packages_ui impacted
+`,
+		},
+		{
+			format: FormatJSON,
+			want: `{
+  "nodes": [
+    {
+      "id": "apps/web",
+      "name": "apps/web",
+      "type": "node",
+      "group": "apps"
+    },
+    {
+      "id": "packages/ui",
+      "name": "packages/ui",
+      "type": "node",
+      "group": "packages",
+      "mark": "impacted"
+    },
+    {
+      "id": "packages/utils",
+      "name": "packages/utils",
+      "type": "node",
+      "group": "packages",
+      "mark": "affected"
+    },
+    {
+      "id": "tools",
+      "name": "tools",
+      "type": "node"
+    }
+  ],
+  "edges": [
+    {
+      "from": "apps/web",
+      "to": "packages/ui",
+      "file": "package.json"
+    },
+    {
+      "from": "packages/ui",
+      "to": "packages/utils",
+      "file": "package.json"
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var out strings.Builder
+			if err := Export(&out, g, tt.format, opts); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Export() =\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestExport_Subgraph(t *testing.T) {
+	g, ws := setupGraph(t, map[string]string{
+		"a/go.mod": "module a\n\nrequire b v0.0.0\n",
+		"b/go.mod": "module b\n\nrequire c v0.0.0\n",
+		"c/go.mod": "module c\n",
+	})
+
+	var out strings.Builder
+	sub := g.Subgraph([]*workspace.Workspace{ws["a"], ws["b"]})
+	if err := Export(&out, sub, FormatMermaid, ExportOptions{Root: filepath.Dir(ws["a"].Path)}); err != nil {
+		t.Fatal(err)
+	}
+	want := "flowchart LR\n  ws_a[\"a\"]\n  ws_b[\"b\"]\n  ws_a --> ws_b\n"
+	if out.String() != want {
+		t.Errorf("Export() = %q, want %q", out.String(), want)
+	}
+}
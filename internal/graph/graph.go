@@ -0,0 +1,299 @@
+// Package graph builds the dependency graph between workspaces from the
+// dependencies declared in their package manifests.
+package graph
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/yuya-takeyama/panama/internal/manifest"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// Edge is a dependency of one workspace on another
+type Edge struct {
+	From *workspace.Workspace
+	To   *workspace.Workspace
+	File string // Manifest declaring the dependency, such as package.json
+}
+
+// Graph holds the dependencies between a set of workspaces
+type Graph struct {
+	nodes      []*workspace.Workspace // Sorted by path
+	edges      []Edge                 // Sorted by the paths of From and To
+	deps       map[*workspace.Workspace][]*workspace.Workspace
+	dependents map[*workspace.Workspace][]*workspace.Workspace
+}
+
+// Build reads the manifests of the workspaces and links each dependency to
+// the workspace it refers to, by directory or by package name. Dependencies
+// on packages outside the workspaces are ignored.
+func Build(workspaces []*workspace.Workspace) *Graph {
+	g := &Graph{
+		nodes:      slices.Clone(workspaces),
+		deps:       make(map[*workspace.Workspace][]*workspace.Workspace),
+		dependents: make(map[*workspace.Workspace][]*workspace.Workspace),
+	}
+	slices.SortFunc(g.nodes, byPath)
+
+	manifests := make(map[*workspace.Workspace][]*manifest.Manifest, len(g.nodes))
+	byDir := make(map[string]*workspace.Workspace, len(g.nodes))
+	byName := make(map[string]*workspace.Workspace)
+	ambiguous := make(map[string]bool)
+	for _, ws := range g.nodes {
+		byDir[ws.Path] = ws
+		manifests[ws] = manifest.Read(ws.Path)
+		for _, m := range manifests[ws] {
+			if m.Name == "" {
+				continue
+			}
+			key := packageKey(m.Type, m.Name)
+			if other, ok := byName[key]; ok && other != ws {
+				ambiguous[key] = true
+			}
+			byName[key] = ws
+		}
+	}
+
+	seen := make(map[[2]*workspace.Workspace]bool)
+	for _, ws := range g.nodes {
+		for _, m := range manifests[ws] {
+			for _, dep := range m.Dependencies {
+				var target *workspace.Workspace
+				if dep.Path != "" {
+					dir := dep.Path
+					if !filepath.IsAbs(dir) {
+						dir = filepath.Join(ws.Path, dir)
+					}
+					target = byDir[filepath.Clean(dir)]
+				} else if key := packageKey(m.Type, dep.Name); !ambiguous[key] {
+					target = byName[key]
+				}
+
+				pair := [2]*workspace.Workspace{ws, target}
+				if target == nil || target == ws || seen[pair] {
+					continue
+				}
+				seen[pair] = true
+				g.edges = append(g.edges, Edge{From: ws, To: target, File: m.File})
+				g.deps[ws] = append(g.deps[ws], target)
+				g.dependents[target] = append(g.dependents[target], ws)
+			}
+		}
+	}
+
+	slices.SortFunc(g.edges, func(a, b Edge) int {
+		return cmp.Or(byPath(a.From, b.From), byPath(a.To, b.To))
+	})
+	for _, adjacent := range []map[*workspace.Workspace][]*workspace.Workspace{g.deps, g.dependents} {
+		for _, list := range adjacent {
+			slices.SortFunc(list, byPath)
+		}
+	}
+	return g
+}
+
+var pythonSeparators = regexp.MustCompile(`[-_.]+`)
+
+// packageKey identifies a package by type and name, normalizing Python
+// names as pip does
+func packageKey(typ, name string) string {
+	if typ == "python" {
+		name = pythonSeparators.ReplaceAllString(strings.ToLower(name), "-")
+	}
+	return typ + ":" + name
+}
+
+func workspaceNames(workspaces []*workspace.Workspace) []string {
+	names := make([]string, len(workspaces))
+	for i, ws := range workspaces {
+		names[i] = ws.Name
+	}
+	return names
+}
+
+func byPath(a, b *workspace.Workspace) int {
+	return strings.Compare(a.Path, b.Path)
+}
+
+// Subgraph returns the graph of the workspaces and the dependencies between
+// them
+func (g *Graph) Subgraph(workspaces []*workspace.Workspace) *Graph {
+	sub := &Graph{
+		nodes:      slices.Clone(workspaces),
+		deps:       make(map[*workspace.Workspace][]*workspace.Workspace),
+		dependents: make(map[*workspace.Workspace][]*workspace.Workspace),
+	}
+	slices.SortFunc(sub.nodes, byPath)
+	sub.nodes = slices.Compact(sub.nodes)
+
+	kept := make(map[*workspace.Workspace]bool, len(sub.nodes))
+	for _, ws := range sub.nodes {
+		kept[ws] = true
+	}
+	for _, e := range g.edges {
+		if kept[e.From] && kept[e.To] {
+			sub.edges = append(sub.edges, e)
+			sub.deps[e.From] = append(sub.deps[e.From], e.To)
+			sub.dependents[e.To] = append(sub.dependents[e.To], e.From)
+		}
+	}
+	return sub
+}
+
+// Workspaces returns the workspaces of the graph sorted by path
+func (g *Graph) Workspaces() []*workspace.Workspace {
+	return g.nodes
+}
+
+// Edges returns the dependencies sorted by the paths of their workspaces
+func (g *Graph) Edges() []Edge {
+	return g.edges
+}
+
+// Dependencies returns the workspaces ws depends on, nearest first, up to
+// depth steps away; a depth of 0 or less follows every step
+func (g *Graph) Dependencies(ws *workspace.Workspace, depth int) []*workspace.Workspace {
+	return walk(g.deps, ws, depth)
+}
+
+// Dependents returns the workspaces depending on ws, nearest first, up to
+// depth steps away; a depth of 0 or less follows every step
+func (g *Graph) Dependents(ws *workspace.Workspace, depth int) []*workspace.Workspace {
+	return walk(g.dependents, ws, depth)
+}
+
+// walk visits the graph breadth first, each step in path order
+func walk(adjacent map[*workspace.Workspace][]*workspace.Workspace, start *workspace.Workspace, depth int) []*workspace.Workspace {
+	visited := map[*workspace.Workspace]bool{start: true}
+	found := []*workspace.Workspace{}
+	level := []*workspace.Workspace{start}
+	for step := 1; len(level) > 0 && (depth <= 0 || step <= depth); step++ {
+		var next []*workspace.Workspace
+		for _, ws := range level {
+			for _, dep := range adjacent[ws] {
+				if !visited[dep] {
+					visited[dep] = true
+					next = append(next, dep)
+				}
+			}
+		}
+		slices.SortFunc(next, byPath)
+		found = append(found, next...)
+		level = next
+	}
+	return found
+}
+
+// Cycles returns the groups of workspaces depending on each other, each
+// sorted by path
+func (g *Graph) Cycles() [][]*workspace.Workspace {
+	// Tarjan's strongly connected components
+	index := make(map[*workspace.Workspace]int)
+	low := make(map[*workspace.Workspace]int)
+	onStack := make(map[*workspace.Workspace]bool)
+	var stack []*workspace.Workspace
+	var cycles [][]*workspace.Workspace
+
+	var connect func(ws *workspace.Workspace)
+	connect = func(ws *workspace.Workspace) {
+		index[ws] = len(index)
+		low[ws] = index[ws]
+		stack = append(stack, ws)
+		onStack[ws] = true
+
+		for _, dep := range g.deps[ws] {
+			if _, ok := index[dep]; !ok {
+				connect(dep)
+				low[ws] = min(low[ws], low[dep])
+			} else if onStack[dep] {
+				low[ws] = min(low[ws], index[dep])
+			}
+		}
+
+		if low[ws] != index[ws] {
+			return
+		}
+		i := len(stack) - 1
+		for stack[i] != ws {
+			i--
+		}
+		component := slices.Clone(stack[i:])
+		for _, member := range component {
+			onStack[member] = false
+		}
+		stack = stack[:i]
+		if len(component) > 1 {
+			slices.SortFunc(component, byPath)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, ws := range g.nodes {
+		if _, ok := index[ws]; !ok {
+			connect(ws)
+		}
+	}
+	slices.SortFunc(cycles, func(a, b []*workspace.Workspace) int { return byPath(a[0], b[0]) })
+	return cycles
+}
+
+// Sort orders the workspaces so that each comes after those it depends on,
+// directly or through workspaces of the graph left out. Workspaces whose
+// dependencies are all placed come in path order, so that independent
+// workspaces stay together. It returns an error when some of the
+// workspaces depend on each other.
+func (g *Graph) Sort(workspaces []*workspace.Workspace) ([]*workspace.Workspace, error) {
+	selected := make(map[*workspace.Workspace]bool, len(workspaces))
+	for _, ws := range workspaces {
+		selected[ws] = true
+	}
+	waiting := make(map[*workspace.Workspace]int, len(workspaces))
+	needed := make(map[*workspace.Workspace][]*workspace.Workspace)
+	for ws := range selected {
+		for _, dep := range g.Dependencies(ws, 0) {
+			if selected[dep] {
+				waiting[ws]++
+				needed[dep] = append(needed[dep], ws)
+			}
+		}
+	}
+
+	var level []*workspace.Workspace
+	for ws := range selected {
+		if waiting[ws] == 0 {
+			level = append(level, ws)
+		}
+	}
+	sorted := make([]*workspace.Workspace, 0, len(selected))
+	for len(level) > 0 {
+		slices.SortFunc(level, byPath)
+		sorted = append(sorted, level...)
+		var next []*workspace.Workspace
+		for _, ws := range level {
+			for _, dependent := range needed[ws] {
+				if waiting[dependent]--; waiting[dependent] == 0 {
+					next = append(next, dependent)
+				}
+			}
+		}
+		level = next
+	}
+
+	if len(sorted) < len(selected) {
+		// Name the cycles holding the workspaces left, rather than those
+		// only waiting for them
+		var names []string
+		for _, cycle := range g.Cycles() {
+			if slices.ContainsFunc(cycle, func(ws *workspace.Workspace) bool { return waiting[ws] > 0 }) {
+				names = append(names, strings.Join(workspaceNames(cycle), " <-> "))
+			}
+		}
+		return nil, fmt.Errorf("dependency cycle: %s", strings.Join(names, "; "))
+	}
+	return sorted, nil
+}
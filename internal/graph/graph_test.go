@@ -0,0 +1,141 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// setupGraph writes the manifest files, keyed by path relative to a
+// temporary directory, and builds the graph of their directories
+func setupGraph(t *testing.T, files map[string]string) (*Graph, map[string]*workspace.Workspace) {
+	t.Helper()
+	root := t.TempDir()
+	byName := make(map[string]*workspace.Workspace)
+	var workspaces []*workspace.Workspace
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		dir := filepath.Dir(name)
+		if _, ok := byName[dir]; !ok {
+			ws := &workspace.Workspace{Name: dir, Path: filepath.Dir(path)}
+			byName[dir] = ws
+			workspaces = append(workspaces, ws)
+		}
+	}
+	return Build(workspaces), byName
+}
+
+func TestBuild(t *testing.T) {
+	g, _ := setupGraph(t, map[string]string{
+		"web/package.json":      `{"name": "@acme/web", "dependencies": {"@acme/ui": "workspace:*", "react": "^18.0.0"}}`,
+		"ui/package.json":       `{"name": "@acme/ui", "devDependencies": {"@acme/config": "file:../config"}}`,
+		"config/package.json":   `{"name": "@acme/config"}`,
+		"api/go.mod":            "module example.com/api\n\nrequire example.com/lib v0.0.0\n",
+		"lib/go.mod":            "module example.com/lib\n",
+		"worker/pyproject.toml": "[project]\nname = \"worker\"\n\n[tool.uv.sources]\nacme_models = { workspace = true }\n",
+		"models/pyproject.toml": "[project]\nname = \"acme-models\"\n",
+		// A package.json of the same name is not a Go module
+		"tools/package.json": `{"name": "example.com/lib", "dependencies": {"example.com/api": "1.0.0"}}`,
+	})
+
+	var edges []string
+	for _, e := range g.Edges() {
+		edges = append(edges, e.From.Name+" -> "+e.To.Name+" ("+e.File+")")
+	}
+	want := []string{
+		"api -> lib (go.mod)",
+		"ui -> config (package.json)",
+		"web -> ui (package.json)",
+		"worker -> models (pyproject.toml)",
+	}
+	if !slices.Equal(edges, want) {
+		t.Errorf("Edges() = %q, want %q", edges, want)
+	}
+}
+
+func TestGraph_Walk(t *testing.T) {
+	g, ws := setupGraph(t, map[string]string{
+		"app/package.json":   `{"name": "app", "dependencies": {"ui": "*", "api": "*"}}`,
+		"ui/package.json":    `{"name": "ui", "dependencies": {"utils": "*"}}`,
+		"api/package.json":   `{"name": "api", "dependencies": {"utils": "*"}}`,
+		"utils/package.json": `{"name": "utils"}`,
+	})
+
+	tests := []struct {
+		name string
+		got  []*workspace.Workspace
+		want []string
+	}{
+		{"direct dependencies", g.Dependencies(ws["app"], 1), []string{"api", "ui"}},
+		{"all dependencies", g.Dependencies(ws["app"], 0), []string{"api", "ui", "utils"}},
+		{"direct dependents", g.Dependents(ws["utils"], 1), []string{"api", "ui"}},
+		{"all dependents", g.Dependents(ws["utils"], 0), []string{"api", "ui", "app"}},
+		{"no dependents", g.Dependents(ws["app"], 0), []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := workspaceNames(tt.got); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraph_Sort(t *testing.T) {
+	g, ws := setupGraph(t, map[string]string{
+		"app/package.json":   `{"name": "app", "dependencies": {"ui": "*", "api": "*"}}`,
+		"ui/package.json":    `{"name": "ui", "dependencies": {"utils": "*"}}`,
+		"api/package.json":   `{"name": "api", "dependencies": {"utils": "*"}}`,
+		"utils/package.json": `{"name": "utils"}`,
+		"docs/package.json":  `{"name": "docs"}`,
+	})
+
+	sorted, err := g.Sort(g.Workspaces())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := workspaceNames(sorted), []string{"docs", "utils", "api", "ui", "app"}; !slices.Equal(got, want) {
+		t.Errorf("Sort() = %v, want %v", got, want)
+	}
+
+	// app still comes after utils with ui and api left out
+	sorted, err = g.Sort([]*workspace.Workspace{ws["app"], ws["utils"]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := workspaceNames(sorted), []string{"utils", "app"}; !slices.Equal(got, want) {
+		t.Errorf("Sort() = %v, want %v", got, want)
+	}
+}
+
+func TestGraph_Cycles(t *testing.T) {
+	g, ws := setupGraph(t, map[string]string{
+		"a/go.mod": "module a\n\nrequire b v0.0.0\n",
+		"b/go.mod": "module b\n\nrequire c v0.0.0\n",
+		"c/go.mod": "module c\n\nrequire a v0.0.0\n",
+		"d/go.mod": "module d\n\nrequire a v0.0.0\n",
+	})
+
+	cycles := g.Cycles()
+	if len(cycles) != 1 || !slices.Equal(workspaceNames(cycles[0]), []string{"a", "b", "c"}) {
+		t.Errorf("Cycles() = %v, want [[a b c]]", cycles)
+	}
+
+	_, err := g.Sort(g.Workspaces())
+	if err == nil || !strings.Contains(err.Error(), "a <-> b <-> c") {
+		t.Errorf("Sort() error = %v, want the cycle of a, b and c", err)
+	}
+	if _, err := g.Sort([]*workspace.Workspace{ws["d"]}); err != nil {
+		t.Errorf("Sort() of a workspace outside the cycle: %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+// Package history records the workspaces chosen with select, so they can be
+// ranked by frecency: how often and how recently they were picked.
+package history
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// maxEntries bounds the history; the entries with the lowest scores are
+// dropped first
+const maxEntries = 1000
+
+// Entry is a recorded workspace
+type Entry struct {
+	Count int       `json:"count"`
+	Last  time.Time `json:"last"`
+}
+
+// History maps workspace paths to their entries
+type History struct {
+	path    string
+	Entries map[string]*Entry `json:"entries"`
+}
+
+// DefaultPath returns the history file, honoring XDG_STATE_HOME and falling
+// back to ~/.local/state/panama/history.json
+func DefaultPath() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "panama", "history.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", "panama", "history.json")
+}
+
+// Load reads the history at path. A missing file is an empty history.
+func Load(path string) (*History, error) {
+	h := &History{path: path, Entries: make(map[string]*Entry)}
+	if path == "" {
+		return h, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("failed to parse history %s: %w", path, err)
+	}
+	if h.Entries == nil {
+		h.Entries = make(map[string]*Entry)
+	}
+	return h, nil
+}
+
+// Record counts a selection of the workspace at path
+func (h *History) Record(path string, now time.Time) {
+	entry, ok := h.Entries[path]
+	if !ok {
+		entry = &Entry{}
+		h.Entries[path] = entry
+	}
+	entry.Count++
+	entry.Last = now
+}
+
+// Score returns the frecency of the workspace at path: its selection count
+// weighted by how recently it was last selected
+func (h *History) Score(path string, now time.Time) float64 {
+	entry, ok := h.Entries[path]
+	if !ok {
+		return 0
+	}
+
+	age := now.Sub(entry.Last)
+	weight := 0.25
+	switch {
+	case age < time.Hour:
+		weight = 4
+	case age < 24*time.Hour:
+		weight = 2
+	case age < 7*24*time.Hour:
+		weight = 0.5
+	}
+	return float64(entry.Count) * weight
+}
+
+// Save writes the history back to the file it was loaded from
+func (h *History) Save() error {
+	if h.path == "" {
+		return fmt.Errorf("no history file")
+	}
+
+	if len(h.Entries) > maxEntries {
+		now := time.Now()
+		paths := make([]string, 0, len(h.Entries))
+		for path := range h.Entries {
+			paths = append(paths, path)
+		}
+		slices.SortFunc(paths, func(a, b string) int {
+			return cmp.Compare(h.Score(b, now), h.Score(a, now))
+		})
+		for _, path := range paths[maxEntries:] {
+			delete(h.Entries, path)
+		}
+	}
+
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+
+	// Replace the file atomically so that concurrent runs never read a
+	// partial history
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), ".history-*")
+	if err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), h.path); err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panama", "history.json")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	h, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	h.Record("/repo/api", now.Add(-30*time.Minute))
+	h.Record("/repo/web", now.Add(-48*time.Hour))
+	h.Record("/repo/web", now.Add(-30*24*time.Hour))
+	h.Record("/repo/web", now.Add(-30*24*time.Hour))
+	if err := h.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	tests := []struct {
+		path string
+		want float64
+	}{
+		{"/repo/api", 4},    // Once, within the hour
+		{"/repo/web", 0.75}, // Three times, last a month ago
+		{"/repo/other", 0},  // Never
+	}
+	for _, tt := range tests {
+		if got := loaded.Score(tt.path, now); got != tt.want {
+			t.Errorf("Score(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSave_DropsLowestScores(t *testing.T) {
+	h, err := Load(filepath.Join(t.TempDir(), "history.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-365 * 24 * time.Hour)
+	for i := range maxEntries {
+		h.Record(filepath.Join("/old", string(rune('a'+i%26)), time.Duration(i).String()), old)
+	}
+	h.Record("/recent", time.Now())
+	if err := h.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Entries) != maxEntries {
+		t.Errorf("kept %d entries, want %d", len(h.Entries), maxEntries)
+	}
+	if _, ok := h.Entries["/recent"]; !ok {
+		t.Error("the most recent entry was dropped")
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected an error for a corrupt history")
+	}
+}
@@ -0,0 +1,216 @@
+package inspect
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// Marker is a file pattern that indicates a workspace root
+type Marker struct {
+	Pattern string
+	Type    string
+}
+
+// KnownMarkers lists the marker files init looks for
+var KnownMarkers = []Marker{
+	{Pattern: "package.json", Type: "node"},
+	{Pattern: "go.mod", Type: "go"},
+	{Pattern: "Cargo.toml", Type: "rust"},
+	{Pattern: "pyproject.toml", Type: "python"},
+	{Pattern: "Chart.yaml", Type: "helm"},
+	{Pattern: "pom.xml", Type: "maven"},
+	{Pattern: "build.gradle", Type: "gradle"},
+	{Pattern: "build.gradle.kts", Type: "gradle"},
+	{Pattern: "Gemfile", Type: "ruby"},
+	{Pattern: "composer.json", Type: "php"},
+	{Pattern: "mix.exs", Type: "elixir"},
+	{Pattern: "Package.swift", Type: "swift"},
+	{Pattern: "pubspec.yaml", Type: "dart"},
+	{Pattern: "deno.json", Type: "deno"},
+	{Pattern: "*.csproj", Type: "dotnet"},
+	{Pattern: "*.xcodeproj", Type: "xcode"},
+}
+
+// KnownIgnoredDirs lists directory names that hold dependencies, build output
+// or caches. They are proposed for ignored_dirs whenever they occur.
+var KnownIgnoredDirs = []string{
+	"node_modules",
+	".git",
+	"vendor",
+	"target",
+	"dist",
+	"build",
+	".next",
+	".nuxt",
+	".cache",
+	"__pycache__",
+	".venv",
+	"venv",
+	".tox",
+	".terraform",
+	".gradle",
+	"coverage",
+	"bower_components",
+}
+
+// DefaultLargeDirThreshold is the number of direct entries above which a
+// directory without markers is considered large
+const DefaultLargeDirThreshold = 1000
+
+type Options struct {
+	MaxDepth          int // Maximum depth to scan (0 means unlimited)
+	LargeDirThreshold int // Entry count above which a directory is proposed for ignoring
+}
+
+// MarkerCount records how often a marker occurred
+type MarkerCount struct {
+	Marker
+	Count int
+}
+
+// DirCount records a directory name proposed for ignored_dirs
+type DirCount struct {
+	Name    string
+	Count   int // Number of directories with this name
+	Entries int // Total direct entries in those directories
+	Known   bool
+}
+
+// Report is the result of inspecting a directory tree
+type Report struct {
+	Root        string
+	Markers     []MarkerCount
+	IgnoredDirs []DirCount
+	MaxDepth    int // Deepest directory containing a marker
+}
+
+// Patterns returns the marker patterns found, most frequent first
+func (r *Report) Patterns() []string {
+	patterns := make([]string, len(r.Markers))
+	for i, m := range r.Markers {
+		patterns[i] = m.Pattern
+	}
+	return patterns
+}
+
+// IgnoreDirNames returns the directory names proposed for ignored_dirs
+func (r *Report) IgnoreDirNames() []string {
+	names := make([]string, len(r.IgnoredDirs))
+	for i, d := range r.IgnoredDirs {
+		names[i] = d.Name
+	}
+	return names
+}
+
+// Inspect scans root and reports which markers occur and which directories
+// should be ignored
+func Inspect(root string, opts Options) (*Report, error) {
+	threshold := opts.LargeDirThreshold
+	if threshold <= 0 {
+		threshold = DefaultLargeDirThreshold
+	}
+
+	markerCounts := make(map[string]int)
+	dirCounts := make(map[string]*DirCount)
+	report := &Report{Root: root}
+
+	addDir := func(name string, entries int, known bool) {
+		dc, ok := dirCounts[name]
+		if !ok {
+			dc = &DirCount{Name: name, Known: known}
+			dirCounts[name] = dc
+		}
+		dc.Count++
+		dc.Entries += entries
+	}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		depth := workspace.CalculateDepth(root, path)
+		if opts.MaxDepth > 0 && depth > opts.MaxDepth {
+			return filepath.SkipDir
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return filepath.SkipDir
+		}
+
+		if path != root && slices.Contains(KnownIgnoredDirs, d.Name()) {
+			addDir(d.Name(), len(entries), true)
+			return filepath.SkipDir
+		}
+
+		found := false
+		for _, m := range KnownMarkers {
+			if matchesAny(m.Pattern, entries) {
+				markerCounts[m.Pattern]++
+				found = true
+			}
+		}
+		if found && depth > report.MaxDepth {
+			report.MaxDepth = depth
+		}
+
+		if path != root && !found && len(entries) > threshold {
+			addDir(d.Name(), len(entries), false)
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range KnownMarkers {
+		if count := markerCounts[m.Pattern]; count > 0 {
+			report.Markers = append(report.Markers, MarkerCount{Marker: m, Count: count})
+		}
+	}
+	sort.SliceStable(report.Markers, func(i, j int) bool {
+		return report.Markers[i].Count > report.Markers[j].Count
+	})
+
+	for _, dc := range dirCounts {
+		report.IgnoredDirs = append(report.IgnoredDirs, *dc)
+	}
+	sort.Slice(report.IgnoredDirs, func(i, j int) bool {
+		a, b := report.IgnoredDirs[i], report.IgnoredDirs[j]
+		if a.Entries != b.Entries {
+			return a.Entries > b.Entries
+		}
+		return a.Name < b.Name
+	})
+
+	return report, nil
+}
+
+func matchesAny(pattern string, entries []fs.DirEntry) bool {
+	glob := strings.ContainsAny(pattern, "*?")
+	for _, entry := range entries {
+		if glob {
+			if matched, _ := filepath.Match(pattern, entry.Name()); matched {
+				return true
+			}
+		} else if entry.Name() == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package inspect
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	root := t.TempDir()
+	files := []string{
+		"package.json",
+		"apps/web/package.json",
+		"apps/admin/package.json",
+		"apps/web/node_modules/react/package.json",
+		"services/api/go.mod",
+		"charts/api/Chart.yaml",
+		"tools/App/App.csproj",
+	}
+	for i := 0; i < 5; i++ {
+		files = append(files, fmt.Sprintf("assets/img%d.png", i))
+	}
+	for _, name := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(""), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Inspect(root, Options{LargeDirThreshold: 3})
+	if err != nil {
+		t.Fatalf("Inspect() error = %v", err)
+	}
+
+	wantPatterns := []string{"package.json", "go.mod", "Chart.yaml", "*.csproj"}
+	if got := report.Patterns(); !slices.Equal(got, wantPatterns) {
+		t.Errorf("Patterns() = %v, want %v", got, wantPatterns)
+	}
+	if report.Markers[0].Count != 3 {
+		t.Errorf("expected 3 package.json files, got %d", report.Markers[0].Count)
+	}
+
+	wantDirs := []string{"assets", "node_modules", ".git"}
+	if got := report.IgnoreDirNames(); !slices.Equal(got, wantDirs) {
+		t.Errorf("IgnoreDirNames() = %v, want %v", got, wantDirs)
+	}
+	for _, d := range report.IgnoredDirs {
+		if d.Name == "assets" && d.Known {
+			t.Errorf("expected assets to be reported as an unknown large directory")
+		}
+	}
+
+	if report.MaxDepth != 2 {
+		t.Errorf("MaxDepth = %d, want 2", report.MaxDepth)
+	}
+}
+
+func TestInspect_MaxDepth(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a", "b", "c", "go.mod")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("module deep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Inspect(root, Options{MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("Inspect() error = %v", err)
+	}
+	if len(report.Markers) != 0 {
+		t.Errorf("expected no markers beyond max depth, got %v", report.Patterns())
+	}
+}
@@ -0,0 +1,337 @@
+package manifest
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/goccy/go-yaml"
+)
+
+// Manifest holds the details read from a package manifest file
+type Manifest struct {
+	File         string       `json:"file"`
+	Type         string       `json:"type"`
+	Name         string       `json:"name,omitempty"`
+	Version      string       `json:"version,omitempty"`
+	Description  string       `json:"description,omitempty"`
+	Dependencies []Dependency `json:"dependencies,omitempty"` // Packages that may live in the same repository
+}
+
+// Dependency is a package a manifest depends on, by name or by local path
+type Dependency struct {
+	Name string `json:"name"`
+	Path string `json:"path,omitempty"` // Directory of the package relative to the manifest, when it is local
+}
+
+// reader parses a manifest file; it returns nil when the file is unusable
+type reader func(path string) *Manifest
+
+var readers = []struct {
+	file string
+	read reader
+}{
+	{"package.json", readPackageJSON},
+	{"go.mod", readGoMod},
+	{"Cargo.toml", readCargoToml},
+	{"pyproject.toml", readPyproject},
+	{"Chart.yaml", readChartYaml},
+}
+
+// Read returns the manifests found in dir, in a fixed order
+func Read(dir string) []*Manifest {
+	var manifests []*Manifest
+	for _, r := range readers {
+		path := filepath.Join(dir, r.file)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if m := r.read(path); m != nil {
+			manifests = append(manifests, m)
+		}
+	}
+	return manifests
+}
+
+func readPackageJSON(path string) *Manifest {
+	var pkg struct {
+		Name                 string            `json:"name"`
+		Version              string            `json:"version"`
+		Description          string            `json:"description"`
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := readJSON(path, &pkg); err != nil {
+		return nil
+	}
+
+	// Every dependency counts, since npm and yarn workspaces link packages
+	// of the repository whatever their range; file: and link: point at a
+	// directory
+	var deps []Dependency
+	for _, group := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.PeerDependencies, pkg.OptionalDependencies} {
+		for name, spec := range group {
+			dep := Dependency{Name: name}
+			for _, prefix := range []string{"file:", "link:", "portal:"} {
+				if after, ok := strings.CutPrefix(spec, prefix); ok {
+					dep.Path = after
+				}
+			}
+			deps = append(deps, dep)
+		}
+	}
+
+	return &Manifest{
+		File:         "package.json",
+		Type:         "node",
+		Name:         pkg.Name,
+		Version:      pkg.Version,
+		Description:  pkg.Description,
+		Dependencies: sortDependencies(deps),
+	}
+}
+
+func readGoMod(path string) *Manifest {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	m := &Manifest{File: "go.mod", Type: "go"}
+	replaced := make(map[string]string)
+	block := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			fields = append([]string{block}, fields...)
+		} else if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "module":
+			m.Name = strings.Trim(fields[1], `"`)
+		case "go":
+			m.Version = "go " + fields[1]
+		case "require":
+			m.Dependencies = append(m.Dependencies, Dependency{Name: strings.Trim(fields[1], `"`)})
+		case "replace":
+			// Only replacements by a directory point into the repository
+			if arrow := slices.Index(fields, "=>"); arrow > 0 && arrow+1 < len(fields) {
+				target := strings.Trim(fields[arrow+1], `"`)
+				if strings.HasPrefix(target, "./") || strings.HasPrefix(target, "../") || filepath.IsAbs(target) {
+					replaced[strings.Trim(fields[1], `"`)] = target
+				}
+			}
+		}
+	}
+
+	// go.work links required modules of the repository; a replace names
+	// their directory
+	for i, dep := range m.Dependencies {
+		m.Dependencies[i].Path = replaced[dep.Name]
+	}
+	return m
+}
+
+func readCargoToml(path string) *Manifest {
+	var cargo struct {
+		Package struct {
+			Name        string `toml:"name"`
+			Version     any    `toml:"version"`
+			Description string `toml:"description"`
+		} `toml:"package"`
+		Dependencies      map[string]any `toml:"dependencies"`
+		DevDependencies   map[string]any `toml:"dev-dependencies"`
+		BuildDependencies map[string]any `toml:"build-dependencies"`
+	}
+	if _, err := toml.DecodeFile(path, &cargo); err != nil {
+		return nil
+	}
+
+	// Crates come from the repository only with a path, or when inherited
+	// from the workspace, which declares their path
+	var deps []Dependency
+	for _, group := range []map[string]any{cargo.Dependencies, cargo.DevDependencies, cargo.BuildDependencies} {
+		for key, spec := range group {
+			table, ok := spec.(map[string]any)
+			if !ok {
+				continue
+			}
+			name := key
+			if pkg, ok := table["package"].(string); ok {
+				name = pkg
+			}
+			if dir, ok := table["path"].(string); ok {
+				deps = append(deps, Dependency{Name: name, Path: dir})
+			} else if table["workspace"] == true {
+				deps = append(deps, Dependency{Name: name})
+			}
+		}
+	}
+
+	// version may be a table when inherited from the workspace
+	version, _ := cargo.Package.Version.(string)
+	return &Manifest{
+		File:         "Cargo.toml",
+		Type:         "rust",
+		Name:         cargo.Package.Name,
+		Version:      version,
+		Description:  cargo.Package.Description,
+		Dependencies: sortDependencies(deps),
+	}
+}
+
+func readPyproject(path string) *Manifest {
+	var pyproject struct {
+		Project struct {
+			Name         string   `toml:"name"`
+			Version      string   `toml:"version"`
+			Description  string   `toml:"description"`
+			Dependencies []string `toml:"dependencies"`
+		} `toml:"project"`
+		Tool struct {
+			Poetry struct {
+				Name            string         `toml:"name"`
+				Version         string         `toml:"version"`
+				Description     string         `toml:"description"`
+				Dependencies    map[string]any `toml:"dependencies"`
+				DevDependencies map[string]any `toml:"dev-dependencies"`
+				Group           map[string]struct {
+					Dependencies map[string]any `toml:"dependencies"`
+				} `toml:"group"`
+			} `toml:"poetry"`
+			UV struct {
+				Sources map[string]any `toml:"sources"`
+			} `toml:"uv"`
+		} `toml:"tool"`
+	}
+	if _, err := toml.DecodeFile(path, &pyproject); err != nil {
+		return nil
+	}
+	m := &Manifest{
+		File:        "pyproject.toml",
+		Type:        "python",
+		Name:        pyproject.Project.Name,
+		Version:     pyproject.Project.Version,
+		Description: pyproject.Project.Description,
+	}
+	poetry := pyproject.Tool.Poetry
+	if m.Name == "" {
+		m.Name, m.Version, m.Description = poetry.Name, poetry.Version, poetry.Description
+	}
+
+	// Local packages are path dependencies of Poetry, path or workspace
+	// sources of uv, and "name @ file:" requirements as PDM writes them
+	var deps []Dependency
+	groups := []map[string]any{poetry.Dependencies, poetry.DevDependencies, pyproject.Tool.UV.Sources}
+	for _, group := range poetry.Group {
+		groups = append(groups, group.Dependencies)
+	}
+	for _, group := range groups {
+		for name, spec := range group {
+			table, ok := spec.(map[string]any)
+			if !ok {
+				continue
+			}
+			if dir, ok := table["path"].(string); ok {
+				deps = append(deps, Dependency{Name: name, Path: dir})
+			} else if table["workspace"] == true {
+				deps = append(deps, Dependency{Name: name})
+			}
+		}
+	}
+	for _, requirement := range pyproject.Project.Dependencies {
+		name, url, ok := strings.Cut(requirement, "@")
+		if !ok {
+			continue
+		}
+		name, _, _ = strings.Cut(name, "[")
+		name = strings.TrimSpace(name)
+		url = strings.TrimSpace(url)
+		if dir, ok := strings.CutPrefix(url, "file:///${PROJECT_ROOT}/"); ok {
+			deps = append(deps, Dependency{Name: name, Path: dir})
+		} else if dir, ok := strings.CutPrefix(url, "file:"); ok && !strings.HasPrefix(dir, "//") {
+			deps = append(deps, Dependency{Name: name, Path: dir})
+		}
+	}
+	m.Dependencies = sortDependencies(deps)
+	return m
+}
+
+func readChartYaml(path string) *Manifest {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var chart struct {
+		Name         string `yaml:"name"`
+		Version      string `yaml:"version"`
+		Description  string `yaml:"description"`
+		Dependencies []struct {
+			Name       string `yaml:"name"`
+			Repository string `yaml:"repository"`
+		} `yaml:"dependencies"`
+	}
+	if err := yaml.Unmarshal(data, &chart); err != nil {
+		return nil
+	}
+
+	// Subcharts in the repository are referenced by a file:// repository
+	var deps []Dependency
+	for _, dep := range chart.Dependencies {
+		if dir, ok := strings.CutPrefix(dep.Repository, "file://"); ok {
+			deps = append(deps, Dependency{Name: dep.Name, Path: dir})
+		}
+	}
+
+	return &Manifest{
+		File:         "Chart.yaml",
+		Type:         "helm",
+		Name:         chart.Name,
+		Version:      chart.Version,
+		Description:  chart.Description,
+		Dependencies: deps,
+	}
+}
+
+// sortDependencies orders dependencies read from maps by name, dropping
+// those repeated in several groups
+func sortDependencies(deps []Dependency) []Dependency {
+	slices.SortStableFunc(deps, func(a, b Dependency) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		// Prefer the entry with a path
+		return strings.Compare(b.Path, a.Path)
+	})
+	return slices.CompactFunc(deps, func(a, b Dependency) bool { return a.Name == b.Name })
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,130 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"package.json":   `{"name": "web", "version": "1.2.0", "description": "Web frontend"}`,
+		"go.mod":         "module github.com/example/api\n\ngo 1.22\n",
+		"pyproject.toml": "[tool.poetry]\nname = \"worker\"\nversion = \"0.3.0\"\n",
+		"Cargo.toml":     "not [valid toml",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manifests := Read(dir)
+
+	want := []Manifest{
+		{File: "package.json", Type: "Node.js", Name: "web", Version: "1.2.0", Description: "Web frontend"},
+		{File: "go.mod", Type: "go", Name: "github.com/example/api", Version: "go 1.22"},
+		{File: "pyproject.toml", Type: "Python", Name: "worker", Version: "0.3.0"},
+	}
+	if len(manifests) != len(want) {
+		t.Fatalf("Read() returned %d manifests, want %d: %+v", len(manifests), len(want), manifests)
+	}
+	for i, m := range manifests {
+		if m.File != want[i].File || m.Name != want[i].Name || m.Version != want[i].Version || m.Description != want[i].Description {
+			t.Errorf("manifest %d = %+v, want %+v", i, *m, want[i])
+		}
+	}
+}
+
+func TestRead_Empty(t *testing.T) {
+	if manifests := Read(t.TempDir()); len(manifests) != 0 {
+		t.Errorf("Read() = %+v, want none", manifests)
+	}
+}
+
+func TestRead_Dependencies(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+		want    []Dependency
+	}{
+		{
+			file: "package.json",
+			content: `{"name": "web", "dependencies": {"ui": "workspace:*", "react": "^18.0.0"},
+				"devDependencies": {"config": "file:../config", "ui": "workspace:^"}}`,
+			want: []Dependency{{Name: "config", Path: "../config"}, {Name: "react"}, {Name: "ui"}},
+		},
+		{
+			file: "go.mod",
+			content: `module example.com/api
+
+require example.com/lib v0.0.0 // indirect
+
+require (
+	example.com/auth v0.0.0
+	golang.org/x/text v0.14.0
+)
+
+replace example.com/lib => ../lib
+
+replace (
+	example.com/auth v0.0.0 => ./internal/auth
+	golang.org/x/text => golang.org/x/text v0.13.0
+)
+`,
+			want: []Dependency{{Name: "example.com/lib", Path: "../lib"}, {Name: "example.com/auth", Path: "./internal/auth"}, {Name: "golang.org/x/text"}},
+		},
+		{
+			file: "Cargo.toml",
+			content: `[package]
+name = "cli"
+
+[dependencies]
+core = { path = "../core" }
+serde = "1"
+proto = { workspace = true }
+
+[dev-dependencies]
+fixtures = { path = "../fixtures", package = "test-fixtures" }
+`,
+			want: []Dependency{{Name: "core", Path: "../core"}, {Name: "proto"}, {Name: "test-fixtures", Path: "../fixtures"}},
+		},
+		{
+			file: "pyproject.toml",
+			content: `[project]
+name = "worker"
+dependencies = ["requests>=2", "common[s3] @ file:///${PROJECT_ROOT}/../common"]
+
+[tool.uv.sources]
+models = { workspace = true }
+
+[tool.poetry.group.dev.dependencies]
+testkit = { path = "../testkit", develop = true }
+`,
+			want: []Dependency{{Name: "common", Path: "../common"}, {Name: "models"}, {Name: "testkit", Path: "../testkit"}},
+		},
+		{
+			file:    "Chart.yaml",
+			content: "name: app\ndependencies:\n  - name: base\n    repository: file://../base\n  - name: redis\n    repository: https://charts.example.com\n",
+			want:    []Dependency{{Name: "base", Path: "../base"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			manifests := Read(dir)
+			if len(manifests) != 1 {
+				t.Fatalf("Read() returned %d manifests, want 1", len(manifests))
+			}
+			if got := manifests[0].Dependencies; !slices.Equal(got, tt.want) {
+				t.Errorf("Dependencies = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
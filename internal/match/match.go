@@ -0,0 +1,141 @@
+// Package match ranks finder labels against the text of a query.
+package match
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ktr0731/go-fuzzyfinder/matching"
+)
+
+// Result is a label matching a pattern
+type Result struct {
+	Idx       int   // Index of the label
+	Score     int   // Higher is better; only comparable within one matcher
+	Positions []int // Matched rune positions in the label, ascending
+}
+
+// Matcher finds the labels matching a single word
+type Matcher interface {
+	Match(pattern string, labels []string) []Result
+}
+
+// Names lists the valid matcher names
+var Names = []string{"fuzzy", "smart"}
+
+// New returns the matcher called name; "" selects fuzzy
+func New(name string) (Matcher, error) {
+	switch name {
+	case "", "fuzzy":
+		return Fuzzy{}, nil
+	case "smart":
+		return Smart{}, nil
+	default:
+		return nil, fmt.Errorf("unknown matcher: %s (must be one of: %s)", name, strings.Join(Names, ", "))
+	}
+}
+
+// Rank returns the labels matching every word of text, best first. Scores
+// of the words are added up. Ties go to the shorter label, then to the
+// earlier one. An empty text matches every label in order.
+func Rank(m Matcher, text string, labels []string) []Result {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		results := make([]Result, len(labels))
+		for i := range labels {
+			results[i] = Result{Idx: i}
+		}
+		return results
+	}
+
+	var results []Result
+	for n, word := range words {
+		found := m.Match(word, labels)
+		if n == 0 {
+			results = found
+			continue
+		}
+
+		byIdx := make(map[int]Result, len(found))
+		for _, r := range found {
+			byIdx[r.Idx] = r
+		}
+		kept := results[:0]
+		for _, r := range results {
+			if other, ok := byIdx[r.Idx]; ok {
+				r.Score += other.Score
+				r.Positions = mergePositions(r.Positions, other.Positions)
+				kept = append(kept, r)
+			}
+		}
+		results = kept
+	}
+
+	slices.SortStableFunc(results, func(a, b Result) int {
+		return cmp.Or(
+			cmp.Compare(b.Score, a.Score),
+			cmp.Compare(utf8.RuneCountInString(labels[a.Idx]), utf8.RuneCountInString(labels[b.Idx])),
+			cmp.Compare(a.Idx, b.Idx),
+		)
+	})
+	return results
+}
+
+func mergePositions(a, b []int) []int {
+	merged := slices.Concat(a, b)
+	slices.Sort(merged)
+	return slices.Compact(merged)
+}
+
+// Fuzzy is the algorithm of go-fuzzyfinder: smart-case subsequence matching
+// scored on the matched characters alone
+type Fuzzy struct{}
+
+func (Fuzzy) Match(pattern string, labels []string) []Result {
+	found := matching.FindAll(pattern, labels)
+	results := make([]Result, len(found))
+	for rank, m := range found {
+		// FindAll sorts by its internal score, which it does not expose
+		results[rank] = Result{
+			Idx:       m.Idx,
+			Score:     len(found) - rank,
+			Positions: subsequencePositions(pattern, labels[m.Idx]),
+		}
+	}
+	return results
+}
+
+// subsequencePositions returns the positions of the first characters of
+// label matching pattern in order, following the smart-case rule
+func subsequencePositions(pattern, label string) []int {
+	fold := !hasUpper(pattern)
+	chars := []rune(pattern)
+	var positions []int
+	for i, r := range []rune(label) {
+		if len(positions) == len(chars) {
+			break
+		}
+		if equal(chars[len(positions)], r, fold) {
+			positions = append(positions, i)
+		}
+	}
+	if len(positions) < len(chars) {
+		return nil
+	}
+	return positions
+}
+
+func hasUpper(s string) bool {
+	return strings.IndexFunc(s, unicode.IsUpper) >= 0
+}
+
+func equal(p, r rune, fold bool) bool {
+	if fold {
+		return p == unicode.ToLower(r)
+	}
+	return p == r
+}
@@ -0,0 +1,123 @@
+package match
+
+import (
+	"slices"
+	"testing"
+)
+
+func ranked(m Matcher, text string, labels []string) []string {
+	var got []string
+	for _, r := range Rank(m, text, labels) {
+		got = append(got, labels[r.Idx])
+	}
+	return got
+}
+
+func TestSmart(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		labels []string
+		want   []string
+	}{
+		{
+			name:   "segment start beats a match inside a word",
+			text:   "api",
+			labels: []string{"services/capital", "services/billing/internal/api_test", "services/api"},
+			want:   []string{"services/api", "services/billing/internal/api_test", "services/capital"},
+		},
+		{
+			name:   "basename prefix beats a directory prefix",
+			text:   "web",
+			labels: []string{"web/legacy/billing", "apps/web"},
+			want:   []string{"apps/web", "web/legacy/billing"},
+		},
+		{
+			name:   "acronym",
+			text:   "bsa",
+			labels: []string{"tools/absurd-args", "services/billing-service-api", "libs/bsa-sdk-old"},
+			want:   []string{"libs/bsa-sdk-old", "services/billing-service-api", "tools/absurd-args"},
+		},
+		{
+			name:   "camel case acronym",
+			text:   "bsa",
+			labels: []string{"apps/Bossanova", "apps/BillingServiceApi"},
+			want:   []string{"apps/BillingServiceApi", "apps/Bossanova"},
+		},
+		{
+			name:   "smart case",
+			text:   "Api",
+			labels: []string{"services/api", "services/Api"},
+			want:   []string{"services/Api"},
+		},
+		{
+			name:   "lower case pattern ignores case",
+			text:   "api",
+			labels: []string{"services/API"},
+			want:   []string{"services/API"},
+		},
+		{
+			name:   "every word must match",
+			text:   "svc api",
+			labels: []string{"apps/api", "services/api", "services/web"},
+			want:   []string{"services/api"},
+		},
+		{
+			name:   "shorter label wins a tie",
+			text:   "api",
+			labels: []string{"x/api-gateway", "x/api-gw"},
+			want:   []string{"x/api-gw", "x/api-gateway"},
+		},
+		{
+			name:   "empty text keeps the order",
+			text:   " ",
+			labels: []string{"b", "a"},
+			want:   []string{"b", "a"},
+		},
+		{
+			name:   "no match",
+			text:   "zzz",
+			labels: []string{"services/api"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ranked(Smart{}, tt.text, tt.labels); !slices.Equal(got, tt.want) {
+				t.Errorf("Rank(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSmart_Positions(t *testing.T) {
+	results := Smart{}.Match("bsa", []string{"services/billing-service-api"})
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if want := []int{9, 17, 25}; !slices.Equal(results[0].Positions, want) {
+		t.Errorf("Positions = %v, want %v", results[0].Positions, want)
+	}
+}
+
+func TestFuzzy(t *testing.T) {
+	labels := []string{"apps/web", "apps/admin", "services/api"}
+	results := Rank(Fuzzy{}, "adn", labels)
+	if len(results) != 1 || results[0].Idx != 1 {
+		t.Fatalf("Rank() = %+v, want apps/admin only", results)
+	}
+	if want := []int{0, 6, 9}; !slices.Equal(results[0].Positions, want) {
+		t.Errorf("Positions = %v, want %v", results[0].Positions, want)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, name := range []string{"", "fuzzy", "smart"} {
+		if _, err := New(name); err != nil {
+			t.Errorf("New(%q) error = %v", name, err)
+		}
+	}
+	if _, err := New("regex"); err == nil {
+		t.Error("expected an error for an unknown matcher")
+	}
+}
@@ -0,0 +1,153 @@
+package match
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Scores of the smart matcher
+const (
+	scoreChar         = 16 // Every matched character
+	bonusSegment      = 24 // Match at the start of the label or after /
+	bonusWord         = 16 // Match after -, _, . or a space
+	bonusCamel        = 12 // Match at an upper-case letter following a lower-case one
+	bonusConsecutive  = 16 // Match right after the previous one
+	bonusBasename     = 4  // Every match in the last path segment
+	bonusAcronym      = 4  // Every character when all of them match at boundaries
+	bonusExactSegment = 32 // Pattern equals the last path segment
+	penaltyGapStart   = 3  // Gap between two matches
+	penaltyGapExtend  = 1  // Every further skipped character
+)
+
+// noMatch marks unreachable states
+const noMatch = math.MinInt / 2
+
+// Smart is panama's matcher. It is case-insensitive unless the pattern has
+// an upper-case letter, and prefers matches at path segment and word starts
+// and in the last path segment, so "api" ranks services/api above
+// services/billing/internal/api_test and "bsa" finds billing-service-api.
+type Smart struct{}
+
+func (Smart) Match(pattern string, labels []string) []Result {
+	chars := []rune(pattern)
+	fold := !hasUpper(pattern)
+	var results []Result
+	for i, label := range labels {
+		if score, positions, ok := scoreLabel(chars, []rune(label), fold); ok {
+			results = append(results, Result{Idx: i, Score: score, Positions: positions})
+		}
+	}
+	return results
+}
+
+// scoreLabel finds the best-scoring alignment of pattern in label
+func scoreLabel(pattern, label []rune, fold bool) (int, []int, bool) {
+	m, n := len(pattern), len(label)
+	if m == 0 || m > n || subsequencePositions(string(pattern), string(label)) == nil {
+		return 0, nil, false
+	}
+
+	basename := 0
+	for j, r := range label {
+		if r == '/' && j < n-1 {
+			basename = j + 1
+		}
+	}
+	bonus := make([]int, n)
+	for j := range label {
+		bonus[j] = charBonus(label, j)
+	}
+
+	// score[i][j] is the best score of pattern[:i+1] with pattern[i] at
+	// label[j]; from[i][j] is the position of pattern[i-1] in that alignment
+	score := make([][]int, m)
+	from := make([][]int, m)
+	for i := range pattern {
+		score[i] = make([]int, n)
+		from[i] = make([]int, n)
+		// run is the best score of pattern[:i] ending two or more characters
+		// before j, less the gap penalty
+		run, runFrom := noMatch, -1
+		for j := range label {
+			score[i][j] = noMatch
+			if i > 0 && j >= 2 {
+				if prev := score[i-1][j-2] - penaltyGapStart; prev >= run-penaltyGapExtend {
+					run, runFrom = prev, j-2
+				} else {
+					run -= penaltyGapExtend
+				}
+			}
+			if j < i || !equal(pattern[i], label[j], fold) {
+				continue
+			}
+
+			gain := scoreChar + bonus[j]
+			if j >= basename {
+				gain += bonusBasename
+			}
+			switch {
+			case i == 0:
+				score[i][j] = gain
+				from[i][j] = -1
+			default:
+				if j > 0 && score[i-1][j-1] > noMatch {
+					score[i][j] = score[i-1][j-1] + gain + bonusConsecutive
+					from[i][j] = j - 1
+				}
+				if run > noMatch && run+gain > score[i][j] {
+					score[i][j] = run + gain
+					from[i][j] = runFrom
+				}
+			}
+		}
+	}
+
+	best, end := noMatch, -1
+	for j, s := range score[m-1] {
+		if s > best {
+			best, end = s, j
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	positions := make([]int, m)
+	for i, j := m-1, end; i >= 0; i-- {
+		positions[i] = j
+		j = from[i][j]
+	}
+
+	acronym := m > 1
+	for _, j := range positions {
+		if bonus[j] == 0 {
+			acronym = false
+		}
+	}
+	if acronym {
+		best += bonusAcronym * m
+	}
+	if strings.EqualFold(string(label[basename:]), string(pattern)) {
+		best += bonusExactSegment
+	}
+	return best, positions, true
+}
+
+// charBonus rewards matching label[j] for the boundary it sits on
+func charBonus(label []rune, j int) int {
+	if j == 0 {
+		return bonusSegment
+	}
+	prev, r := label[j-1], label[j]
+	switch {
+	case prev == '/':
+		return bonusSegment
+	case prev == '-' || prev == '_' || prev == '.' || unicode.IsSpace(prev):
+		return bonusWord
+	case unicode.IsLower(prev) && unicode.IsUpper(r):
+		return bonusCamel
+	default:
+		return 0
+	}
+}
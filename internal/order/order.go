@@ -0,0 +1,171 @@
+// Package order sorts workspaces for the finder and list.
+package order
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuya-takeyama/panama/internal/history"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// Order is a sort order
+type Order string
+
+const (
+	Path     Order = "path"     // Path, alphabetically
+	Name     Order = "name"     // Directory name, alphabetically
+	Depth    Order = "depth"    // Shallow workspaces first
+	Modified Order = "modified" // Most recently modified files first
+	Commit   Order = "commit"   // Most recent git commit first
+	Frecency Order = "frecency" // Most often and recently selected first
+)
+
+// Orders lists the valid orders
+var Orders = []Order{Path, Name, Depth, Modified, Commit, Frecency}
+
+// Parse converts a --sort value to an Order
+func Parse(s string) (Order, error) {
+	if o := Order(s); slices.Contains(Orders, o) {
+		return o, nil
+	}
+	names := make([]string, len(Orders))
+	for i, o := range Orders {
+		names[i] = string(o)
+	}
+	return "", fmt.Errorf("invalid sort order: %s (must be one of: %s)", s, strings.Join(names, ", "))
+}
+
+// Options configures Sort
+type Options struct {
+	Order      Order
+	Reverse    bool
+	IgnoreDirs []string         // Directories skipped when looking for modified files
+	History    *history.History // Selections for the frecency order
+}
+
+// workers bounds the file walks and git commands run at once
+const workers = 8
+
+// gitTimeout bounds each git log call
+const gitTimeout = 5 * time.Second
+
+// Sort sorts workspaces in place. Workspaces comparing equal keep their
+// path order, and Reverse flips the whole result.
+func Sort(workspaces []*workspace.Workspace, opts Options) {
+	slices.SortFunc(workspaces, func(a, b *workspace.Workspace) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	switch opts.Order {
+	case Name:
+		slices.SortStableFunc(workspaces, func(a, b *workspace.Workspace) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+	case Depth:
+		slices.SortStableFunc(workspaces, func(a, b *workspace.Workspace) int {
+			return cmp.Compare(a.Depth, b.Depth)
+		})
+	case Modified:
+		nested := make(map[string]bool, len(workspaces))
+		for _, ws := range workspaces {
+			nested[ws.Path] = true
+		}
+		sortByTime(workspaces, func(ws *workspace.Workspace) time.Time {
+			return lastModified(ws.Path, opts.IgnoreDirs, nested)
+		})
+	case Commit:
+		sortByTime(workspaces, func(ws *workspace.Workspace) time.Time {
+			return lastCommit(ws.Path)
+		})
+	case Frecency:
+		if opts.History != nil {
+			now := time.Now()
+			slices.SortStableFunc(workspaces, func(a, b *workspace.Workspace) int {
+				return cmp.Compare(opts.History.Score(b.Path, now), opts.History.Score(a.Path, now))
+			})
+		}
+	}
+
+	if opts.Reverse {
+		slices.Reverse(workspaces)
+	}
+}
+
+// sortByTime sorts the workspaces newest first, computing the times in
+// parallel
+func sortByTime(workspaces []*workspace.Workspace, timeOf func(*workspace.Workspace) time.Time) {
+	times := make(map[string]time.Time, len(workspaces))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	for _, ws := range workspaces {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			t := timeOf(ws)
+			mu.Lock()
+			times[ws.Path] = t
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	slices.SortStableFunc(workspaces, func(a, b *workspace.Workspace) int {
+		return times[b.Path].Compare(times[a.Path])
+	})
+}
+
+// lastModified returns the newest modification time of the files in dir,
+// skipping .git, ignored directories and nested workspaces
+func lastModified(dir string, ignoreDirs []string, workspaces map[string]bool) time.Time {
+	var latest time.Time
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable entries
+			if d != nil && d.IsDir() && path != dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && (d.Name() == ".git" || slices.Contains(ignoreDirs, d.Name()) || workspaces[path]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest
+}
+
+// lastCommit returns the time of the latest commit touching dir, or the
+// zero time outside a git work tree
+func lastCommit(dir string) time.Time {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "log", "-1", "--format=%ct", "--", ".").Output()
+	if err != nil {
+		return time.Time{}
+	}
+	seconds, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
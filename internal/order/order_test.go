@@ -0,0 +1,137 @@
+package order
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/yuya-takeyama/panama/internal/history"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+func names(workspaces []*workspace.Workspace) []string {
+	result := make([]string, len(workspaces))
+	for i, ws := range workspaces {
+		result[i] = ws.Name
+	}
+	return result
+}
+
+// touch creates dir/name with the given modification time
+func touch(t *testing.T, dir, name string, mtime time.Time) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSort(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	ws := func(rel string, depth int) *workspace.Workspace {
+		return &workspace.Workspace{Path: filepath.Join(root, rel), Name: filepath.Base(rel), Depth: depth}
+	}
+	newList := func() []*workspace.Workspace {
+		return []*workspace.Workspace{ws("b/api", 2), ws("a/zeta", 2), ws("c", 1), ws("b", 1)}
+	}
+
+	touch(t, root, "a/zeta/main.go", now.Add(-time.Hour))
+	touch(t, root, "b/api/main.go", now.Add(-48*time.Hour))
+	touch(t, root, "b/README.md", now.Add(-24*time.Hour))
+	touch(t, root, "b/node_modules/x.js", now)
+	touch(t, root, "c/main.go", now.Add(-72*time.Hour))
+
+	h, err := history.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Record(filepath.Join(root, "c"), now)
+	h.Record(filepath.Join(root, "c"), now)
+	h.Record(filepath.Join(root, "b/api"), now)
+
+	tests := []struct {
+		opts Options
+		want []string
+	}{
+		{Options{Order: Path}, []string{"zeta", "b", "api", "c"}},
+		{Options{Order: Path, Reverse: true}, []string{"c", "api", "b", "zeta"}},
+		{Options{Order: Name}, []string{"api", "b", "c", "zeta"}},
+		{Options{Order: Depth}, []string{"b", "c", "zeta", "api"}},
+		// node_modules is ignored and b/api is a workspace of its own
+		{Options{Order: Modified, IgnoreDirs: []string{"node_modules"}}, []string{"zeta", "b", "api", "c"}},
+		{Options{Order: Frecency, History: h}, []string{"c", "api", "zeta", "b"}},
+		{Options{Order: Frecency, History: h, Reverse: true}, []string{"b", "zeta", "api", "c"}},
+	}
+
+	for _, tt := range tests {
+		name := string(tt.opts.Order)
+		if tt.opts.Reverse {
+			name += " reversed"
+		}
+		t.Run(name, func(t *testing.T) {
+			workspaces := newList()
+			Sort(workspaces, tt.opts)
+			if got := names(workspaces); !slices.Equal(got, tt.want) {
+				t.Errorf("Sort() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSort_Commit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	commit := func(file, date string) {
+		t.Helper()
+		touch(t, root, file, time.Now())
+		for _, args := range [][]string{{"add", "-A"}, {"commit", "-q", "-m", file}} {
+			cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+			cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v: %s", args, err, out)
+			}
+		}
+	}
+	if out, err := exec.Command("git", "init", "-q", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	commit("old/main.go", "2020-01-01T00:00:00Z")
+	commit("new/main.go", "2024-01-01T00:00:00Z")
+	commit("mid/main.go", "2022-01-01T00:00:00Z")
+	if err := os.MkdirAll(filepath.Join(root, "untracked"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var workspaces []*workspace.Workspace
+	for _, name := range []string{"mid", "new", "old", "untracked"} {
+		workspaces = append(workspaces, &workspace.Workspace{Path: filepath.Join(root, name), Name: name})
+	}
+	Sort(workspaces, Options{Order: Commit})
+	if got, want := names(workspaces), []string{"new", "mid", "old", "untracked"}; !slices.Equal(got, want) {
+		t.Errorf("Sort() = %v, want %v", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, o := range Orders {
+		if got, err := Parse(string(o)); err != nil || got != o {
+			t.Errorf("Parse(%q) = %q, %v", o, got, err)
+		}
+	}
+	if _, err := Parse("size"); err == nil {
+		t.Error("expected an error for an unknown order")
+	}
+}
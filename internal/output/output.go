@@ -14,6 +14,7 @@ const (
 	FormatPath Format = "path"
 	FormatCD   Format = "cd"
 	FormatJSON Format = "json"
+	FormatNUL  Format = "nul" // Paths terminated by NUL bytes, for xargs -0
 )
 
 func Print(path string, format Format) error {
@@ -22,6 +23,8 @@ func Print(path string, format Format) error {
 		fmt.Println(path)
 	case FormatCD:
 		fmt.Printf("cd \"%s\"\n", path)
+	case FormatNUL:
+		fmt.Printf("%s\x00", path)
 	case FormatJSON:
 		data := map[string]string{"path": path}
 		encoder := json.NewEncoder(os.Stdout)
@@ -41,6 +44,10 @@ func PrintWorkspaces(workspaces []*workspace.Workspace, format Format) error {
 		for _, ws := range workspaces {
 			fmt.Println(ws.Path)
 		}
+	case FormatNUL:
+		for _, ws := range workspaces {
+			fmt.Printf("%s\x00", ws.Path)
+		}
 	case FormatJSON:
 		encoder := json.NewEncoder(os.Stdout)
 		encoder.SetIndent("", "  ")
@@ -61,6 +68,8 @@ func ParseFormat(s string) (Format, error) {
 		return FormatCD, nil
 	case "json":
 		return FormatJSON, nil
+	case "nul":
+		return FormatNUL, nil
 	default:
 		return "", fmt.Errorf("invalid format: %s", s)
 	}
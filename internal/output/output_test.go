@@ -29,6 +29,12 @@ func TestParseFormat(t *testing.T) {
 			want:    FormatJSON,
 			wantErr: false,
 		},
+		{
+			name:    "nul format",
+			input:   "nul",
+			want:    FormatNUL,
+			wantErr: false,
+		},
 		{
 			name:    "invalid format",
 			input:   "invalid",
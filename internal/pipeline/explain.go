@@ -0,0 +1,144 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// Step is a single decision made while replaying the walk
+type Step struct {
+	Path    string `json:"path"`
+	Depth   int    `json:"depth"`
+	Message string `json:"message"`
+}
+
+// Explanation describes how the pipeline treats a single directory
+type Explanation struct {
+	Target     string `json:"target"`
+	Root       string `json:"root"`
+	ConfigFile string `json:"config_file,omitempty"`
+	Listed     bool   `json:"listed"`  // Whether the target is reported as a workspace
+	Verdict    string `json:"verdict"` // Summary of the final decision
+	Steps      []Step `json:"steps"`
+}
+
+// Explain replays the walk from rootDir down to target and records every
+// decision that affects whether target is reported as a workspace
+func Explain(rootDir, target string, cfg *config.Config, opts Options) (*Explanation, error) {
+	rel, err := filepath.Rel(rootDir, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%s is outside the search root %s", target, rootDir)
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", target)
+	}
+
+	exp := &Explanation{
+		Target:     target,
+		Root:       rootDir,
+		ConfigFile: cfg.ConfigFile,
+	}
+
+	// Directories visited on the way from the root to the target
+	chain := []string{rootDir}
+	if rel != "." {
+		dir := rootDir
+		for _, part := range strings.Split(rel, string(filepath.Separator)) {
+			dir = filepath.Join(dir, part)
+			chain = append(chain, dir)
+		}
+	}
+
+	current := newRootScope(rootDir, cfg, opts)
+	for _, dir := range chain {
+		isRoot := dir == rootDir
+		isTarget := dir == target
+		parent := current
+
+		v := current.visit(dir, rootDir, isRoot)
+		step := func(format string, args ...any) {
+			exp.Steps = append(exp.Steps, Step{Path: dir, Depth: v.depth, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if v.overDepth {
+			step("pruned: depth %d exceeds max_depth %d%s", workspace.CalculateDepth(parent.depthBase, dir), parent.maxDepth, describeSource(parent))
+			exp.Verdict = fmt.Sprintf("not listed: %s is beyond max_depth", relativeTo(rootDir, dir))
+			return exp, nil
+		}
+		if v.ignoredBy != "" {
+			step("pruned: matches ignored_dirs pattern %q", v.ignoredBy)
+			exp.Verdict = fmt.Sprintf("not listed: %s is ignored", relativeTo(rootDir, dir))
+			return exp, nil
+		}
+
+		if v.configErr != nil {
+			step("nested config ignored: %v", v.configErr)
+		}
+		if v.scope != parent {
+			step("applies nested config %s", v.scope.cfg.ConfigFile)
+		}
+
+		switch {
+		case v.match != "" && isTarget:
+			step("workspace: matched %s", describeRule(v.match))
+			exp.Listed = true
+			exp.Verdict = "listed as a workspace"
+			return exp, nil
+		case v.match != "" && !isRoot:
+			step("workspace: matched %s; its subtree is not searched", describeRule(v.match))
+			exp.Verdict = fmt.Sprintf("not listed: inside workspace %s", relativeTo(rootDir, dir))
+			return exp, nil
+		case v.match != "":
+			step("workspace: matched %s; the search root is always descended", describeRule(v.match))
+		case isTarget:
+			step("not a workspace: no .git directory and no pattern matched (patterns: %s)", describePatterns(v.scope.detector.Patterns()))
+			exp.Verdict = "not listed: no workspace marker"
+			return exp, nil
+		default:
+			step("descend")
+		}
+
+		current = v.scope
+	}
+
+	return exp, nil
+}
+
+func describeRule(rule string) string {
+	if rule == ".git" {
+		return ".git directory"
+	}
+	return fmt.Sprintf("pattern %q", rule)
+}
+
+func describePatterns(patterns []string) string {
+	if len(patterns) == 0 {
+		return "none configured"
+	}
+	return strings.Join(patterns, ", ")
+}
+
+func describeSource(s *scope) string {
+	if s.depthSource == "" {
+		return ""
+	}
+	return " (from " + s.depthSource + ")"
+}
+
+func relativeTo(base, path string) string {
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,97 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yuya-takeyama/panama/internal/config"
+)
+
+func TestExplain(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"services/api/go.mod":                 "module api",
+		"services/api/internal/handlers/x.go": "package handlers",
+		"web/node_modules/dep/package.json":   "{}",
+		"a/b/c/go.mod":                        "module deep",
+		"docs/guide/README.md":                "# Guide",
+	})
+
+	cfg := config.DefaultConfig()
+	cfg.Patterns = []string{"package.json", "go.mod"}
+	cfg.IgnoreDirs = []string{"node_modules"}
+	cfg.MaxDepth = 2
+
+	tests := []struct {
+		name        string
+		target      string
+		wantListed  bool
+		wantVerdict string
+		wantMessage string
+	}{
+		{
+			name:        "listed workspace",
+			target:      "services/api",
+			wantListed:  true,
+			wantVerdict: "listed as a workspace",
+			wantMessage: `matched pattern "go.mod"`,
+		},
+		{
+			name:        "inside another workspace",
+			target:      "services/api/internal/handlers",
+			wantVerdict: "not listed: inside workspace services/api",
+			wantMessage: "its subtree is not searched",
+		},
+		{
+			name:        "ignored directory",
+			target:      "web/node_modules/dep",
+			wantVerdict: "not listed: web/node_modules is ignored",
+			wantMessage: `"**/node_modules"`,
+		},
+		{
+			name:        "beyond max depth",
+			target:      "a/b/c",
+			wantVerdict: "not listed: a/b/c is beyond max_depth",
+			wantMessage: "depth 3 exceeds max_depth 2",
+		},
+		{
+			name:        "no marker",
+			target:      "docs/guide",
+			wantVerdict: "not listed: no workspace marker",
+			wantMessage: "patterns: package.json, go.mod",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp, err := Explain(root, filepath.Join(root, tt.target), cfg, Options{})
+			if err != nil {
+				t.Fatalf("Explain() error = %v", err)
+			}
+			if exp.Listed != tt.wantListed {
+				t.Errorf("Listed = %v, want %v", exp.Listed, tt.wantListed)
+			}
+			if exp.Verdict != filepath.FromSlash(tt.wantVerdict) {
+				t.Errorf("Verdict = %q, want %q", exp.Verdict, tt.wantVerdict)
+			}
+			last := exp.Steps[len(exp.Steps)-1]
+			if !strings.Contains(last.Message, tt.wantMessage) {
+				t.Errorf("last step = %q, want it to contain %q", last.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestExplain_OutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	other := t.TempDir()
+	if err := os.MkdirAll(other, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Explain(root, other, config.DefaultConfig(), Options{}); err == nil {
+		t.Error("expected error for a target outside the search root")
+	}
+}
@@ -1,12 +1,19 @@
 package pipeline
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
+	"github.com/yuya-takeyama/panama/internal/codeowners"
 	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/tasks"
 	"github.com/yuya-takeyama/panama/internal/workspace"
 )
 
@@ -14,43 +21,214 @@ type Options struct {
 	Query    string
 	MaxDepth int
 	NoCache  bool
+	Stats    bool // Record per-directory timings in the result
 }
 
-func CollectWorkspaces(rootDir string, cfg *config.Config, opts Options) ([]*workspace.Workspace, error) {
-	workspaces := []*workspace.Workspace{}
-	maxDepth := cfg.MaxDepth
-	if opts.MaxDepth > 0 {
-		maxDepth = opts.MaxDepth
-	}
+// scope holds the settings in effect for a subtree. Nested configuration
+// files start a new scope for the directory they are found in.
+type scope struct {
+	cfg            *config.Config
+	detector       *workspace.Detector
+	ignorePatterns []string
+	depthBase      string // Directory max depth is measured from
+	maxDepth       int
+	depthSource    string // Where maxDepth was configured
+}
 
+func newScope(cfg *config.Config, depthBase string, maxDepth int) *scope {
 	// Create ignore patterns
 	ignorePatterns := make([]string, len(cfg.IgnoreDirs))
 	for i, dir := range cfg.IgnoreDirs {
 		ignorePatterns[i] = "**/" + dir
 	}
 
-	// Create detector with custom patterns
-	detector := workspace.NewDetector(cfg.Patterns)
+	return &scope{
+		cfg:            cfg,
+		detector:       workspace.NewDetector(cfg.Patterns),
+		ignorePatterns: ignorePatterns,
+		depthBase:      depthBase,
+		maxDepth:       maxDepth,
+		depthSource:    cfg.ConfigFile,
+	}
+}
+
+// newRootScope returns the scope for the search root
+func newRootScope(rootDir string, cfg *config.Config, opts Options) *scope {
+	if opts.MaxDepth > 0 {
+		s := newScope(cfg, rootDir, opts.MaxDepth)
+		s.depthSource = "--max-depth"
+		return s
+	}
+	return newScope(cfg, rootDir, cfg.MaxDepth)
+}
+
+// enter returns the scope for dir, applying a nested configuration file if
+// dir has one. The parent scope is kept when the nested file is invalid.
+func (s *scope) enter(dir string) (*scope, error) {
+	cfg, err := config.LoadOverride(dir, s.cfg)
+	if err != nil {
+		return s, err
+	}
+	if cfg == nil {
+		return s, nil
+	}
+
+	if cfg.Has("max_depth") {
+		// Nested max_depth is relative to the directory declaring it
+		return newScope(cfg, dir, cfg.MaxDepth), nil
+	}
+	nested := newScope(cfg, s.depthBase, s.maxDepth)
+	nested.depthSource = s.depthSource
+	return nested, nil
+}
+
+// visit is the outcome of evaluating a single directory during the walk
+type visit struct {
+	scope     *scope // Scope in effect for the directory and its subtree
+	depth     int    // Depth relative to the search root
+	overDepth bool   // Pruned because it exceeds the scope's max depth
+	ignoredBy string // Ignore pattern that pruned the directory
+	match     string // Detector rule that made it a workspace
+	configErr error  // Failure loading a nested configuration file
+}
+
+func (v visit) pruned() bool {
+	return v.overDepth || v.ignoredBy != ""
+}
+
+// visit evaluates dir with the settings of s, the scope of its parent
+func (s *scope) visit(dir, basePath string, isRoot bool) visit {
+	v := visit{
+		scope: s,
+		depth: workspace.CalculateDepth(basePath, dir),
+	}
+
+	// Skip if exceeds max depth
+	if workspace.CalculateDepth(s.depthBase, dir) > s.maxDepth {
+		v.overDepth = true
+		return v
+	}
+
+	// Skip ignored directories
+	for _, pattern := range s.ignorePatterns {
+		if matched, _ := doublestar.Match(pattern, dir); matched {
+			v.ignoredBy = pattern
+			return v
+		}
+	}
+
+	// Apply nested configuration for this subtree
+	if !isRoot {
+		v.scope, v.configErr = s.enter(dir)
+	}
+
+	v.match = v.scope.detector.Match(dir)
+	return v
+}
+
+var (
+	// ErrBrokenSymlink is reported for symlinks whose target does not exist
+	ErrBrokenSymlink = errors.New("broken symlink")
+	// ErrNestedConfig is reported for nested configuration files that fail to load
+	ErrNestedConfig = errors.New("invalid nested config")
+	// ErrWorkspaceFile is reported for .panama-workspace.yaml files that fail to load
+	ErrWorkspaceFile = errors.New("invalid workspace file")
+)
+
+// WalkError is a problem encountered while walking a directory. Walk errors
+// do not stop the search; the affected subtree is skipped.
+type WalkError struct {
+	Path string `json:"path"`
+	Err  error  `json:"-"`
+}
+
+func (e *WalkError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *WalkError) Unwrap() error {
+	return e.Err
+}
+
+// DirStat records the time spent in a single directory
+type DirStat struct {
+	Path    string
+	Elapsed time.Duration
+}
+
+// Result is the outcome of a workspace search
+type Result struct {
+	Workspaces []*workspace.Workspace
+	Errors     []*WalkError
+	Visited    int           // Number of directories visited
+	Elapsed    time.Duration // Total time spent walking
+	Stats      []DirStat     // Per-directory timings, collected when Options.Stats is set
+}
+
+func CollectWorkspaces(rootDir string, cfg *config.Config, opts Options) ([]*workspace.Workspace, error) {
+	result, err := Collect(rootDir, cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	return result.Workspaces, nil
+}
+
+// Collect searches rootDir for workspaces and reports the problems found
+// along the way
+func Collect(rootDir string, cfg *config.Config, opts Options) (*Result, error) {
+	result := &Result{Workspaces: []*workspace.Workspace{}}
+	root := newRootScope(rootDir, cfg, opts)
 
 	visited := make(map[string]bool)
 
 	// Search from root directory
-	if err := collectFromPath(rootDir, rootDir, maxDepth, ignorePatterns, visited, detector, &workspaces); err != nil {
+	start := time.Now()
+	if err := collectFromPath(rootDir, rootDir, root, visited, opts.Stats, result); err != nil {
 		return nil, err
 	}
+	result.Elapsed = time.Since(start)
 
 	// Sort workspaces by path
-	sort.Slice(workspaces, func(i, j int) bool {
-		return workspaces[i].Path < workspaces[j].Path
+	sort.Slice(result.Workspaces, func(i, j int) bool {
+		return result.Workspaces[i].Path < result.Workspaces[j].Path
 	})
 
-	return workspaces, nil
+	return result, nil
 }
 
-func collectFromPath(searchPath, basePath string, maxDepth int, ignorePatterns []string, visited map[string]bool, detector *workspace.Detector, workspaces *[]*workspace.Workspace) error {
+func collectFromPath(searchPath, basePath string, root *scope, visited map[string]bool, stats bool, result *Result) error {
+	scopes := map[string]*scope{searchPath: root}
+
+	// Time between callbacks is attributed to the previously visited
+	// directory, since Walk reads its entries before moving on
+	lastDir, lastTime := "", time.Now()
+	record := func(next string) {
+		if !stats {
+			return
+		}
+		now := time.Now()
+		if lastDir != "" {
+			result.Stats = append(result.Stats, DirStat{Path: lastDir, Elapsed: now.Sub(lastTime)})
+		}
+		lastDir, lastTime = next, now
+	}
+	defer record("")
+
 	return filepath.Walk(searchPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			return nil // Skip on error
+			// Record the error and skip the unreadable entry
+			result.Errors = append(result.Errors, &WalkError{Path: path, Err: err})
+			if info != nil && info.IsDir() && path != searchPath {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			if _, err := os.Stat(path); err != nil {
+				result.Errors = append(result.Errors, &WalkError{Path: path, Err: fmt.Errorf("%w: %v", ErrBrokenSymlink, err)})
+			}
+			return nil
 		}
 
 		if !info.IsDir() {
@@ -62,34 +240,28 @@ func collectFromPath(searchPath, basePath string, maxDepth int, ignorePatterns [
 			return filepath.SkipDir
 		}
 		visited[path] = true
+		record(path)
+		result.Visited++
 
-		// Skip if exceeds max depth
-		depth := workspace.CalculateDepth(basePath, path)
-		if depth > maxDepth {
-			return filepath.SkipDir
+		current := root
+		if path != searchPath {
+			if parent, ok := scopes[filepath.Dir(path)]; ok {
+				current = parent
+			}
 		}
 
-		// Skip ignored directories
-		for _, pattern := range ignorePatterns {
-			if matched, _ := doublestar.Match(pattern, path); matched {
-				return filepath.SkipDir
-			}
+		v := current.visit(path, basePath, path == searchPath)
+		if v.configErr != nil {
+			result.Errors = append(result.Errors, &WalkError{Path: path, Err: fmt.Errorf("%w: %v", ErrNestedConfig, v.configErr)})
+		}
+		if v.pruned() {
+			return filepath.SkipDir
 		}
+		scopes[path] = v.scope
 
 		// Check if it's a workspace
-		if detector.IsWorkspaceWithPatterns(path) {
-			ws := &workspace.Workspace{
-				Path:  path,
-				Name:  filepath.Base(path),
-				Depth: depth,
-			}
-
-			// Add package type as description
-			if packageType := workspace.GetPackageType(path); packageType != "" {
-				ws.Description = "Type: " + packageType
-			}
-
-			*workspaces = append(*workspaces, ws)
+		if v.match != "" {
+			result.Workspaces = append(result.Workspaces, newWorkspace(path, v.depth))
 
 			// Don't recurse into detected workspaces
 			if path != searchPath {
@@ -100,3 +272,129 @@ func collectFromPath(searchPath, basePath string, maxDepth int, ignorePatterns [
 		return nil
 	})
 }
+
+// Describer fills in the details of workspaces: their tasks, their owners
+// and their metadata from the workspaces section of the configuration and
+// their .panama-workspace.yaml files. It is safe for concurrent use.
+type Describer struct {
+	cfg    *config.Config // Configuration of the search root; nested files cannot describe workspaces
+	owners *codeowners.Index
+
+	mu        sync.Mutex
+	described map[*workspace.Workspace]workspace.Details
+}
+
+func NewDescriber(cfg *config.Config) *Describer {
+	return &Describer{
+		cfg:       cfg,
+		owners:    codeowners.NewIndex(),
+		described: make(map[*workspace.Workspace]workspace.Details),
+	}
+}
+
+// Describe fills in the details of ws not filled in before, so it can be
+// called each time they are about to be used. Owners include those given
+// in metadata, which is filled in along with them. An invalid
+// .panama-workspace.yaml is reported the first time metadata is asked for,
+// and ws is described without it.
+func (d *Describer) Describe(ws *workspace.Workspace, details workspace.Details) error {
+	if details&workspace.DetailOwners != 0 {
+		details |= workspace.DetailMetadata
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	missing := details &^ d.described[ws]
+	d.described[ws] |= missing
+
+	var err error
+	if missing&workspace.DetailMetadata != 0 {
+		err = d.describeMetadata(ws)
+	}
+	if missing&workspace.DetailTasks != 0 {
+		ws.Tasks = tasks.Discover(ws.Path)
+	}
+	if missing&workspace.DetailOwners != 0 {
+		// CODEOWNERS comes before the owners the workspace adds itself
+		ws.Owners = appendUnique(slices.Clone(d.owners.Owners(ws.Path)), ws.Owners)
+	}
+	return err
+}
+
+// describeMetadata applies the entries of the workspaces section matching
+// ws, then its .panama-workspace.yaml
+func (d *Describer) describeMetadata(ws *workspace.Workspace) error {
+	var described []config.WorkspaceConfig
+	if len(d.cfg.Workspaces) > 0 {
+		if rel, err := filepath.Rel(d.cfg.ConfigDir, ws.Path); err == nil {
+			described = d.cfg.WorkspaceConfigs(filepath.ToSlash(rel))
+		}
+	}
+	file, err := config.LoadWorkspaceFile(ws.Path)
+	if file != nil {
+		described = append(described, *file)
+	}
+	for _, wc := range described {
+		describe(ws, wc)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWorkspaceFile, err)
+	}
+	return nil
+}
+
+// Describe fills in the details of the workspaces found, recording invalid
+// .panama-workspace.yaml files among the errors
+func (r *Result) Describe(describer *Describer, details workspace.Details) {
+	for _, ws := range r.Workspaces {
+		if err := describer.Describe(ws, details); err != nil {
+			r.Errors = append(r.Errors, &WalkError{Path: ws.Path, Err: err})
+		}
+	}
+}
+
+// newWorkspace returns the workspace at path, without details
+func newWorkspace(path string, depth int) *workspace.Workspace {
+	ws := &workspace.Workspace{
+		Path:  path,
+		Name:  filepath.Base(path),
+		Depth: depth,
+	}
+
+	// Add package type as description
+	if packageType := workspace.GetPackageType(path); packageType != "" {
+		ws.Type = packageType
+		ws.Description = "Type: " + packageType
+	}
+	return ws
+}
+
+// describe applies wc to ws. Descriptions and editors replace earlier
+// ones, lists are extended and meta values are set key by key.
+func describe(ws *workspace.Workspace, wc config.WorkspaceConfig) {
+	if wc.Description != "" {
+		ws.Description = wc.Description
+	}
+	if wc.Editor != "" {
+		ws.Editor = wc.Editor
+	}
+	ws.Tags = appendUnique(ws.Tags, wc.Tags)
+	ws.Aliases = appendUnique(ws.Aliases, wc.Aliases)
+	ws.Owners = appendUnique(ws.Owners, wc.Owners)
+	for key, value := range wc.Meta {
+		if ws.Meta == nil {
+			ws.Meta = make(map[string]string, len(wc.Meta))
+		}
+		ws.Meta[key] = value
+	}
+}
+
+func appendUnique(list, extra []string) []string {
+	for _, item := range extra {
+		if !slices.Contains(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,253 @@
+package pipeline
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// writeFiles creates files under root, creating parent directories as needed
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func relativePaths(t *testing.T, root string, cfg *config.Config, opts Options) []string {
+	t.Helper()
+	workspaces, err := CollectWorkspaces(root, cfg, opts)
+	if err != nil {
+		t.Fatalf("CollectWorkspaces() error = %v", err)
+	}
+	paths := make([]string, len(workspaces))
+	for i, ws := range workspaces {
+		paths[i] = filepath.ToSlash(ws.RelativePath(root))
+	}
+	return paths
+}
+
+func TestCollectWorkspaces(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"apps/web/package.json":                  "{}",
+		"apps/web/node_modules/dep/package.json": "{}",
+		"services/api/go.mod":                    "module api",
+		"a/b/c/d/go.mod":                         "module deep",
+	})
+
+	cfg := config.DefaultConfig()
+	cfg.Patterns = []string{"package.json", "go.mod"}
+	cfg.IgnoreDirs = []string{"node_modules"}
+	cfg.MaxDepth = 3
+
+	got := relativePaths(t, root, cfg, Options{})
+	want := []string{"apps/web", "services/api"}
+	if !slices.Equal(got, want) {
+		t.Errorf("CollectWorkspaces() = %v, want %v", got, want)
+	}
+}
+
+func TestCollectWorkspaces_NestedConfig(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"apps/web/package.json":            "{}",
+		"apps/web/fixtures/package.json":   "{}",
+		"charts/.panama.yaml":              "patterns:\n  - Chart.yaml\nmax_depth: 1\n",
+		"charts/api/Chart.yaml":            "name: api",
+		"charts/api/nested/Chart.yaml":     "name: nested",
+		"charts/group/deep/Chart.yaml":     "name: deep",
+		"legacy/.panama.yaml":              "ignored_dirs:\n  - fixtures\n",
+		"legacy/app/fixtures/package.json": "{}",
+		"legacy/app/src/package.json":      "{}",
+		"other/Chart.yaml":                 "name: other",
+	})
+
+	cfg := config.DefaultConfig()
+	cfg.Patterns = []string{"package.json"}
+
+	got := relativePaths(t, root, cfg, Options{})
+	want := []string{"apps/web", "charts/api", "legacy/app/src"}
+	if !slices.Equal(got, want) {
+		t.Errorf("CollectWorkspaces() = %v, want %v", got, want)
+	}
+}
+
+func TestCollect_WalkErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on Windows")
+	}
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"svc/go.mod":              "module svc",
+		"broken/.panama.yaml":     "max_depth: [invalid\n",
+		"broken/app/package.json": "{}",
+	})
+	if err := os.Symlink(filepath.Join(root, "missing"), filepath.Join(root, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Patterns = []string{"go.mod", "package.json"}
+
+	result, err := Collect(root, cfg, Options{Stats: true})
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	if len(result.Workspaces) != 2 {
+		t.Errorf("expected 2 workspaces, got %d", len(result.Workspaces))
+	}
+	if len(result.Errors) != 2 {
+		t.Fatalf("expected 2 walk errors, got %v", result.Errors)
+	}
+	if !errors.Is(result.Errors[0], ErrNestedConfig) {
+		t.Errorf("expected nested config error, got %v", result.Errors[0])
+	}
+	if !errors.Is(result.Errors[1], ErrBrokenSymlink) {
+		t.Errorf("expected broken symlink error, got %v", result.Errors[1])
+	}
+	if result.Visited == 0 || len(result.Stats) != result.Visited {
+		t.Errorf("expected stats for each of %d visited directories, got %d", result.Visited, len(result.Stats))
+	}
+}
+
+func TestDescriber_Metadata(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"services/api/go.mod": "module api",
+		"services/api/.panama-workspace.yaml": "description: Public API\naliases: [gateway]\ntags: [tier-1, backend]\n" +
+			"owners: ['@acme/api']\neditor: code -n\nmeta:\n  slo: '99.9'\n",
+		"services/worker/go.mod":                 "module worker",
+		"services/worker/.panama-workspace.yaml": "tags: [oops\n",
+		"libs/log/go.mod":                        "module log",
+	})
+
+	cfg := config.DefaultConfig()
+	cfg.ConfigDir = root
+	cfg.Patterns = []string{"go.mod"}
+	cfg.Workspaces = map[string]config.WorkspaceConfig{
+		"services/*":   {Tags: []string{"backend"}, Meta: map[string]string{"slo": "99", "tier": "2"}},
+		"services/api": {Description: "API", Owners: []string{"@acme/platform"}},
+	}
+
+	result, err := Collect(root, cfg, Options{})
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(result.Workspaces) != 3 {
+		t.Fatalf("expected 3 workspaces, got %d", len(result.Workspaces))
+	}
+
+	logWs, api, worker := result.Workspaces[0], result.Workspaces[1], result.Workspaces[2]
+	if api.Description != "Type: go" || api.Tags != nil || len(result.Errors) != 0 {
+		t.Errorf("api = %+v with errors %v, want metadata left out while collecting", api, result.Errors)
+	}
+
+	result.Describe(NewDescriber(cfg), workspace.DetailMetadata)
+	if logWs.Description != "Type: go" || logWs.Tags != nil {
+		t.Errorf("libs/log = %+v, want no metadata", logWs)
+	}
+	if api.Description != "Public API" || api.Editor != "code -n" {
+		t.Errorf("api description, editor = %q, %q", api.Description, api.Editor)
+	}
+	if !slices.Equal(api.Tags, []string{"backend", "tier-1"}) || !slices.Equal(api.Aliases, []string{"gateway"}) {
+		t.Errorf("api tags, aliases = %v, %v", api.Tags, api.Aliases)
+	}
+	if !slices.Equal(api.Owners, []string{"@acme/platform", "@acme/api"}) {
+		t.Errorf("api owners = %v", api.Owners)
+	}
+	if api.Meta["slo"] != "99.9" || api.Meta["tier"] != "2" {
+		t.Errorf("api meta = %v", api.Meta)
+	}
+
+	// The invalid file is reported and the workspace kept with the rest
+	if !slices.Equal(worker.Tags, []string{"backend"}) {
+		t.Errorf("worker tags = %v", worker.Tags)
+	}
+	if len(result.Errors) != 1 || !errors.Is(result.Errors[0], ErrWorkspaceFile) {
+		t.Errorf("expected a workspace file error, got %v", result.Errors)
+	}
+}
+
+func TestDescriber_Tasks(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"web/package.json": `{"scripts": {"dev": "vite"}}`,
+	})
+
+	cfg := config.DefaultConfig()
+	cfg.Patterns = []string{"package.json"}
+
+	workspaces, err := CollectWorkspaces(root, cfg, Options{})
+	if err != nil {
+		t.Fatalf("CollectWorkspaces() error = %v", err)
+	}
+	if len(workspaces) != 1 {
+		t.Fatalf("expected 1 workspace, got %d", len(workspaces))
+	}
+	web := workspaces[0]
+	if web.Tasks != nil {
+		t.Errorf("tasks discovered while collecting: %v", web.Tasks)
+	}
+
+	describer := NewDescriber(cfg)
+	describer.Describe(web, workspace.DetailTasks)
+	if len(web.Tasks) != 1 || web.Tasks[0].Name != "dev" {
+		t.Fatalf("tasks = %v, want dev", web.Tasks)
+	}
+
+	// Details already filled in are not read again
+	if err := os.Remove(filepath.Join(root, "web", "package.json")); err != nil {
+		t.Fatal(err)
+	}
+	describer.Describe(web, workspace.AllDetails)
+	if len(web.Tasks) != 1 {
+		t.Errorf("tasks = %v after describing again", web.Tasks)
+	}
+}
+
+func TestDescriber_Owners(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/HEAD":           "ref: refs/heads/main\n",
+		".github/CODEOWNERS":  "/services/ @acme/backend\n",
+		"services/api/go.mod": "module api",
+	})
+
+	cfg := config.DefaultConfig()
+	cfg.ConfigDir = root
+	cfg.Patterns = []string{"go.mod"}
+	cfg.Workspaces = map[string]config.WorkspaceConfig{
+		"services/api": {Owners: []string{"@alice", "@acme/backend"}},
+	}
+
+	workspaces, err := CollectWorkspaces(root, cfg, Options{})
+	if err != nil {
+		t.Fatalf("CollectWorkspaces() error = %v", err)
+	}
+	api := workspaces[len(workspaces)-1] // After the repository root
+	if api.Owners != nil {
+		t.Errorf("owners looked up while collecting: %v", api.Owners)
+	}
+
+	if err := NewDescriber(cfg).Describe(api, workspace.DetailOwners); err != nil {
+		t.Fatalf("Describe() error = %v", err)
+	}
+	if !slices.Equal(api.Owners, []string{"@acme/backend", "@alice"}) {
+		t.Errorf("owners = %v, want CODEOWNERS first, then the configured ones", api.Owners)
+	}
+}
@@ -0,0 +1,138 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// Resolver finds the workspaces containing a path without searching the
+// whole tree. It replays the walk from the root down to the path, so the
+// answer matches what Collect reports, and caches the directories it visits
+// so that many paths in the same tree stay fast.
+type Resolver struct {
+	root      string
+	scope     *scope
+	visits    map[string]visit
+	found     map[string]*workspace.Workspace
+	describer *Describer
+}
+
+// NewResolver returns a Resolver for the workspaces below rootDir
+func NewResolver(rootDir string, cfg *config.Config, opts Options) *Resolver {
+	return &Resolver{
+		root:      rootDir,
+		scope:     newRootScope(rootDir, cfg, opts),
+		visits:    make(map[string]visit),
+		found:     make(map[string]*workspace.Workspace),
+		describer: NewDescriber(cfg),
+	}
+}
+
+// Describer returns the Describer of the workspaces the resolver finds, to
+// fill in their details
+func (r *Resolver) Describer() *Describer {
+	return r.describer
+}
+
+// Root returns the search root
+func (r *Resolver) Root() string {
+	return r.root
+}
+
+// Resolve returns the workspaces containing path, outermost first. The last
+// one is the workspace path belongs to. Since Collect does not search inside
+// workspaces, only the search root can enclose another workspace. path does
+// not need to exist, so deleted files resolve too.
+func (r *Resolver) Resolve(path string) ([]*workspace.Workspace, error) {
+	chain, err := r.chain(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var enclosing []*workspace.Workspace
+	current := r.scope
+	for _, dir := range chain {
+		isRoot := dir == r.root
+		v, ok := r.visits[dir]
+		if !ok {
+			v = current.visit(dir, r.root, isRoot)
+			r.visits[dir] = v
+		}
+		if v.pruned() {
+			break
+		}
+		if v.match != "" {
+			ws, ok := r.found[dir]
+			if !ok {
+				ws = newWorkspace(dir, v.depth)
+				r.found[dir] = ws
+			}
+			enclosing = append(enclosing, ws)
+			if !isRoot {
+				break
+			}
+		}
+		current = v.scope
+	}
+	return enclosing, nil
+}
+
+// Ancestors returns the directories from path up to the root that the
+// detector in effect there recognizes as workspaces, nearest first. Unlike
+// Resolve, it reports workspaces inside other workspaces, and max_depth and
+// ignored_dirs do not apply.
+func (r *Resolver) Ancestors(path string) ([]*workspace.Workspace, error) {
+	chain, err := r.chain(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ancestors []*workspace.Workspace
+	current := r.scope
+	for _, dir := range chain {
+		if dir != r.root {
+			// Invalid nested configuration files are reported by list
+			current, _ = current.enter(dir)
+		}
+		if current.detector.Match(dir) != "" {
+			ws, ok := r.found[dir]
+			if !ok {
+				ws = newWorkspace(dir, workspace.CalculateDepth(r.root, dir))
+				r.found[dir] = ws
+			}
+			ancestors = append(ancestors, ws)
+		}
+	}
+	slices.Reverse(ancestors)
+	return ancestors, nil
+}
+
+// chain returns the directories from the root down to path, or to the
+// directory containing path when it is not a directory. Symlinked
+// directories end the chain, since Collect does not walk into them.
+func (r *Resolver) chain(path string) ([]string, error) {
+	rel, err := filepath.Rel(r.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%s is outside the search root %s", path, r.root)
+	}
+
+	chain := []string{r.root}
+	if rel != "." {
+		dir := r.root
+		for _, part := range strings.Split(rel, string(filepath.Separator)) {
+			dir = filepath.Join(dir, part)
+			// Like Collect, stop at symlinks rather than following them
+			if info, err := os.Lstat(dir); err != nil || !info.IsDir() {
+				break
+			}
+			chain = append(chain, dir)
+		}
+	}
+	return chain, nil
+}
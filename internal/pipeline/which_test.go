@@ -0,0 +1,163 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+func TestResolver_Resolve(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"services/api/go.mod":                  "module api",
+		"services/api/internal/handlers/x.go":  "package handlers",
+		"services/api/plugins/auth/go.mod":     "module auth",
+		"web/node_modules/dep/package.json":    "{}",
+		"web/node_modules/dep/index.js":        "",
+		"charts/.panama.yaml":                  "patterns:\n  - Chart.yaml\n",
+		"charts/app/Chart.yaml":                "name: app",
+		"charts/app/templates/deployment.yaml": "",
+		"docs/guide/README.md":                 "# Guide",
+	})
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Patterns = []string{"package.json", "go.mod"}
+	cfg.IgnoreDirs = []string{"node_modules"}
+
+	tests := []struct {
+		path string
+		want []string // Workspaces relative to the root, outermost first
+	}{
+		{path: "services/api/internal/handlers/x.go", want: []string{".", "services/api"}},
+		{path: "services/api", want: []string{".", "services/api"}},
+		{path: "services/api/plugins/auth/go.mod", want: []string{".", "services/api"}},
+		{path: "services/api/deleted.go", want: []string{".", "services/api"}},
+		{path: "web/node_modules/dep/index.js", want: []string{"."}},
+		{path: "charts/app/templates/deployment.yaml", want: []string{".", "charts/app"}},
+		{path: "docs/guide/README.md", want: []string{"."}},
+		{path: "README.md", want: []string{"."}},
+	}
+
+	r := NewResolver(root, cfg, Options{})
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			enclosing, err := r.Resolve(filepath.Join(root, filepath.FromSlash(tt.path)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, ws := range enclosing {
+				got = append(got, filepath.ToSlash(ws.RelativePath(root)))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Resolve(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	// Resolved workspaces are the ones Collect reports
+	result, err := Collect(root, cfg, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enclosing, _ := r.Resolve(filepath.Join(root, "charts", "app", "Chart.yaml"))
+	if i := slices.IndexFunc(result.Workspaces, func(ws *workspace.Workspace) bool { return ws.Path == enclosing[1].Path }); i < 0 {
+		t.Errorf("Collect did not report %s", enclosing[1].Path)
+	} else if !reflect.DeepEqual(result.Workspaces[i], enclosing[1]) {
+		t.Errorf("Resolve() = %+v, Collect() = %+v", enclosing[1], result.Workspaces[i])
+	}
+
+	if _, err := r.Resolve(filepath.Dir(root)); err == nil {
+		t.Error("Resolve() outside the root did not fail")
+	}
+}
+
+func TestResolver_Symlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on Windows")
+	}
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"services/api/go.mod":    "module api",
+		"services/api/server.go": "package api",
+	})
+	if err := os.Symlink(filepath.Join(root, "services", "api"), filepath.Join(root, "api")); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Patterns = []string{"go.mod"}
+
+	// Collect skips the link, so files through it belong to no workspace
+	if got := relativePaths(t, root, cfg, Options{}); !slices.Equal(got, []string{"services/api"}) {
+		t.Fatalf("CollectWorkspaces() = %v, want services/api only", got)
+	}
+	r := NewResolver(root, cfg, Options{})
+	for _, path := range []string{"api/server.go", "api"} {
+		enclosing, err := r.Resolve(filepath.Join(root, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(enclosing) != 0 {
+			t.Errorf("Resolve(%s) = %v, want no workspace", path, enclosing)
+		}
+	}
+}
+
+func TestResolver_Ancestors(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"services/api/go.mod":                  "module api",
+		"services/api/plugins/auth/go.mod":     "module auth",
+		"services/api/plugins/auth/src/x.go":   "package auth",
+		"web/node_modules/dep/package.json":    "{}",
+		"charts/.panama.yaml":                  "patterns:\n  - Chart.yaml\n",
+		"charts/app/Chart.yaml":                "name: app",
+		"charts/app/templates/deployment.yaml": "",
+	})
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Patterns = []string{"package.json", "go.mod"}
+	cfg.IgnoreDirs = []string{"node_modules"}
+	cfg.MaxDepth = 2
+
+	tests := []struct {
+		path string
+		want []string // Workspaces relative to the root, nearest first
+	}{
+		{path: "services/api/plugins/auth/src/x.go", want: []string{"services/api/plugins/auth", "services/api", "."}},
+		{path: "services/api", want: []string{"services/api", "."}},
+		{path: "web/node_modules/dep/index.js", want: []string{"web/node_modules/dep", "."}},
+		{path: "charts/app/templates/deployment.yaml", want: []string{"charts/app", "."}},
+	}
+
+	r := NewResolver(root, cfg, Options{})
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			ancestors, err := r.Ancestors(filepath.Join(root, filepath.FromSlash(tt.path)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, ws := range ancestors {
+				got = append(got, filepath.ToSlash(ws.RelativePath(root)))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Ancestors(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
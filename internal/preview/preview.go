@@ -0,0 +1,235 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/manifest"
+	"github.com/yuya-takeyama/panama/internal/shell"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+const (
+	// maxTreeEntries limits the entries listed per directory in the file tree
+	maxTreeEntries = 15
+	// maxTasks limits the tasks listed
+	maxTasks = 15
+	// commandTimeout bounds custom preview commands and git
+	commandTimeout = 3 * time.Second
+)
+
+var readmeNames = []string{"README.md", "README", "README.txt", "README.rst", "readme.md", "Readme.md"}
+
+// Renderer builds preview text for workspaces. Each workspace is rendered
+// on first request and cached, so moving the cursor back is instant.
+type Renderer struct {
+	cfg        config.PreviewConfig
+	ignoreDirs []string
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// New creates a Renderer. Directories named in ignoreDirs are left out of the
+// file tree.
+func New(cfg config.PreviewConfig, ignoreDirs []string) *Renderer {
+	return &Renderer{
+		cfg:        cfg,
+		ignoreDirs: ignoreDirs,
+		cache:      make(map[string]string),
+	}
+}
+
+// Render returns the preview for ws
+func (r *Renderer) Render(ws *workspace.Workspace) string {
+	r.mu.Lock()
+	if text, ok := r.cache[ws.Path]; ok {
+		r.mu.Unlock()
+		return text
+	}
+	r.mu.Unlock()
+
+	var text string
+	if r.cfg.Command != "" {
+		text = r.runCommand(ws.Path)
+	} else {
+		text = r.build(ws)
+	}
+
+	r.mu.Lock()
+	r.cache[ws.Path] = text
+	r.mu.Unlock()
+	return text
+}
+
+func (r *Renderer) build(ws *workspace.Workspace) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s\n", ws.Name)
+	fmt.Fprintf(&b, "Path: %s\n", ws.Path)
+	if ws.Description != "" {
+		fmt.Fprintf(&b, "%s\n", ws.Description)
+	}
+	if len(ws.Owners) > 0 {
+		fmt.Fprintf(&b, "Owners: %s\n", strings.Join(ws.Owners, ", "))
+	}
+	if len(ws.Aliases) > 0 {
+		fmt.Fprintf(&b, "Aliases: %s\n", strings.Join(ws.Aliases, ", "))
+	}
+	if len(ws.Tags) > 0 {
+		fmt.Fprintf(&b, "Tags: %s\n", strings.Join(ws.Tags, ", "))
+	}
+	if len(ws.Meta) > 0 {
+		section(&b, "Meta")
+		for _, key := range slices.Sorted(maps.Keys(ws.Meta)) {
+			writeField(&b, key, ws.Meta[key])
+		}
+	}
+
+	for _, m := range manifest.Read(ws.Path) {
+		section(&b, m.File)
+		writeField(&b, "name", m.Name)
+		writeField(&b, "version", m.Version)
+		writeField(&b, "description", m.Description)
+	}
+
+	if len(ws.Tasks) > 0 {
+		section(&b, "Tasks")
+		for i, task := range ws.Tasks {
+			if i == maxTasks {
+				fmt.Fprintf(&b, "… %d more\n", len(ws.Tasks)-i)
+				break
+			}
+			writeField(&b, task.Name, task.Command)
+		}
+	}
+
+	if r.cfg.ReadmeLines > 0 {
+		if lines := readmeLines(ws.Path, r.cfg.ReadmeLines); len(lines) > 0 {
+			section(&b, "README")
+			for _, line := range lines {
+				fmt.Fprintf(&b, "%s\n", line)
+			}
+		}
+	}
+
+	if r.cfg.TreeDepth > 0 {
+		section(&b, "Files")
+		r.writeTree(&b, ws.Path, "", 1)
+	}
+
+	if r.cfg.Commits > 0 {
+		if commits := recentCommits(ws.Path, r.cfg.Commits); commits != "" {
+			section(&b, "Recent commits")
+			b.WriteString(commits)
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func section(b *strings.Builder, title string) {
+	fmt.Fprintf(b, "\n── %s ──\n", title)
+}
+
+func writeField(b *strings.Builder, name, value string) {
+	if value != "" {
+		fmt.Fprintf(b, "%s: %s\n", name, value)
+	}
+}
+
+func readmeLines(dir string, n int) []string {
+	for _, name := range readmeNames {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+		if len(lines) > n {
+			lines = lines[:n]
+		}
+		return lines
+	}
+	return nil
+}
+
+// writeTree writes a shallow listing of dir, directories first
+func (r *Renderer) writeTree(b *strings.Builder, dir, indent string, depth int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	entries = slices.DeleteFunc(entries, func(e os.DirEntry) bool {
+		return e.Name() == ".git" || (e.IsDir() && slices.Contains(r.ignoreDirs, e.Name()))
+	})
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].IsDir() && !entries[j].IsDir()
+	})
+
+	for i, entry := range entries {
+		if i == maxTreeEntries {
+			fmt.Fprintf(b, "%s└── … %d more\n", indent, len(entries)-i)
+			break
+		}
+
+		last := i == len(entries)-1
+		branch, childIndent := "├── ", indent+"│   "
+		if last {
+			branch, childIndent = "└── ", indent+"    "
+		}
+
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		fmt.Fprintf(b, "%s%s%s\n", indent, branch, name)
+
+		if entry.IsDir() && depth < r.cfg.TreeDepth {
+			r.writeTree(b, filepath.Join(dir, entry.Name()), childIndent, depth+1)
+		}
+	}
+}
+
+func recentCommits(dir string, n int) string {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "-C", dir, "log", "-n", fmt.Sprint(n),
+		"--format=%h %s (%cr, %an)", "--", ".")
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// runCommand runs the configured preview command for dir, replacing {} with
+// the quoted path, and returns its combined output
+func (r *Renderer) runCommand(dir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	cmd := shell.Command(ctx, shell.Expand(r.cfg.Command, dir))
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "PANAMA_WORKSPACE="+dir)
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(&out, "\n[preview command failed: %v]", err)
+	}
+	return out.String()
+}
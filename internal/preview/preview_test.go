@@ -0,0 +1,104 @@
+package preview
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yuya-takeyama/panama/internal/config"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRenderer_Render(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"README.md":                   "# Web\n\nThe web frontend.\nline 4\n",
+		"package.json":                `{"name": "web", "version": "1.0.0"}`,
+		"src/index.ts":                "",
+		"src/components/Button.tsx":   "",
+		"node_modules/react/index.js": "",
+	})
+
+	cfg := config.PreviewConfig{ReadmeLines: 3, TreeDepth: 1}
+	r := New(cfg, []string{"node_modules"})
+	text := r.Render(&workspace.Workspace{Path: dir, Name: "web", Owners: []string{"@acme/web", "@alice"}, Tags: []string{"frontend"}, Meta: map[string]string{"tier": "1"}, Tasks: []workspace.Task{
+		{Name: "dev", Source: "package.json", Command: "npm run dev"},
+	}})
+
+	for _, want := range []string{"web\n", "Owners: @acme/web, @alice\n", "Tags: frontend\n", "── Meta ──\ntier: 1\n", "── package.json ──", "version: 1.0.0", "── Tasks ──\ndev: npm run dev\n", "# Web", "The web frontend.", "├── src/", "└── package.json"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("preview does not contain %q:\n%s", want, text)
+		}
+	}
+	for _, unwanted := range []string{"line 4", "node_modules", "Button.tsx"} {
+		if strings.Contains(text, unwanted) {
+			t.Errorf("preview contains %q:\n%s", unwanted, text)
+		}
+	}
+}
+
+func TestRenderer_Command(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	r := New(config.PreviewConfig{Command: `echo arg={}; echo "env=$PANAMA_WORKSPACE"; pwd`}, nil)
+	text := r.Render(&workspace.Workspace{Path: dir, Name: "web"})
+
+	if !strings.Contains(text, "arg="+dir+"\nenv="+dir) {
+		t.Errorf("unexpected command output:\n%s", text)
+	}
+	if wd, _ := filepath.EvalSymlinks(dir); !strings.Contains(text, wd) {
+		t.Errorf("command did not run in the workspace:\n%s", text)
+	}
+
+	// Rendered previews are cached
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if again := r.Render(&workspace.Workspace{Path: dir, Name: "web"}); again != text {
+		t.Errorf("expected cached preview, got:\n%s", again)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  string
+	}{
+		{"short", "abc", 5, "abc"},
+		{"ascii", "abcdefg", 3, "abc\ndef\ng"},
+		{"wide characters", "日本語テキスト", 6, "日本語\nテキス\nト"},
+		{"wide character at edge", "a日本", 4, "a日\n本"},
+		{"ansi", "\x1b[31mabcd\x1b[0m", 2, "\x1b[31mab\ncd\x1b[0m"},
+		{"tabs", "a\tb", 6, "a   b"},
+		{"multiple lines", "ab\ncdef", 3, "ab\ncde\nf"},
+		{"zero width", "abcdef", 0, "abcdef"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Wrap(tt.text, tt.width); got != tt.want {
+				t.Errorf("Wrap(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package preview
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+const tabWidth = 4
+
+// Wrap breaks the lines of text so that none is wider than width terminal
+// cells. Wide characters count as two cells and ANSI escape sequences as
+// none, so colored command output and CJK text wrap where they are drawn.
+func Wrap(text string, width int) string {
+	if width <= 0 {
+		return text
+	}
+
+	var b strings.Builder
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		wrapLine(&b, strings.TrimSuffix(line, "\r"), width)
+	}
+	return b.String()
+}
+
+func wrapLine(b *strings.Builder, line string, width int) {
+	col := 0
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		// Copy escape sequences through without counting their width
+		if r == '\x1b' {
+			end := escapeEnd(runes, i)
+			b.WriteString(string(runes[i:end]))
+			i = end - 1
+			continue
+		}
+
+		if r == '\t' {
+			spaces := tabWidth - col%tabWidth
+			if col+spaces > width {
+				b.WriteByte('\n')
+				col = 0
+				spaces = tabWidth
+			}
+			b.WriteString(strings.Repeat(" ", spaces))
+			col += spaces
+			continue
+		}
+
+		w := runewidth.RuneWidth(r)
+		if col+w > width && col > 0 {
+			b.WriteByte('\n')
+			col = 0
+		}
+		b.WriteRune(r)
+		col += w
+	}
+}
+
+// escapeEnd returns the index just past the escape sequence starting at i
+func escapeEnd(runes []rune, i int) int {
+	j := i + 1
+	if j >= len(runes) || runes[j] != '[' {
+		return min(j+1, len(runes))
+	}
+	// CSI sequences end with a byte in the range @ to ~
+	for j++; j < len(runes); j++ {
+		if runes[j] >= '@' && runes[j] <= '~' {
+			return j + 1
+		}
+	}
+	return len(runes)
+}
@@ -0,0 +1,182 @@
+package query
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuya-takeyama/panama/internal/changes"
+	"github.com/yuya-takeyama/panama/internal/manifest"
+	"github.com/yuya-takeyama/panama/internal/match"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// Matcher evaluates queries against the workspaces found below a search
+// root. Package names and git changes are looked up on first use and cached,
+// so a Matcher can be reused while the query is being typed. Workspace
+// details are filled in by the describer when a term needs them.
+type Matcher struct {
+	root      string
+	describer workspace.Describer // Nil when details are filled in already
+	packages  map[string][]string // Manifest package names by workspace path
+	trees     map[string]string   // Git work tree containing each directory
+	changed   map[changeKey][]string
+}
+
+type changeKey struct {
+	tree, ref string
+}
+
+func NewMatcher(root string, describer workspace.Describer) *Matcher {
+	return &Matcher{
+		root:      root,
+		describer: describer,
+		packages:  make(map[string][]string),
+		trees:     make(map[string]string),
+		changed:   make(map[changeKey][]string),
+	}
+}
+
+// Filter returns the workspaces matching every term of q, keeping their
+// order. The free text is matched against their relative paths by text, the
+// matcher the finder ranks with.
+func (m *Matcher) Filter(q *Query, workspaces []*workspace.Workspace, text match.Matcher) ([]*workspace.Workspace, error) {
+	var matched []*workspace.Workspace
+	var labels []string
+	for _, ws := range workspaces {
+		ok, err := m.Match(q, ws)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, ws)
+			labels = append(labels, ws.RelativePath(m.root))
+		}
+	}
+
+	found := make([]bool, len(matched))
+	for _, r := range match.Rank(text, q.Text, labels) {
+		found[r.Idx] = true
+	}
+	filtered := make([]*workspace.Workspace, 0, len(matched))
+	for i, ws := range matched {
+		if found[i] {
+			filtered = append(filtered, ws)
+		}
+	}
+	return filtered, nil
+}
+
+// Match reports whether ws satisfies the structured and negated terms of q.
+// Free text is left to the caller, which usually ranks it.
+func (m *Matcher) Match(q *Query, ws *workspace.Workspace) (bool, error) {
+	for _, t := range q.terms {
+		ok, err := m.matchTerm(t, ws)
+		if err != nil {
+			return false, err
+		}
+		if ok == t.negate {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (m *Matcher) matchTerm(t term, ws *workspace.Workspace) (bool, error) {
+	switch t.key {
+	case "":
+		return strings.Contains(strings.ToLower(ws.RelativePath(m.root)), t.value), nil
+	case "type":
+		return matchGlob(t.value, ws.Type), nil
+	case "name":
+		m.describe(ws, workspace.DetailMetadata)
+		return matchGlob(t.value, ws.Name) || matchAny(t.value, ws.Aliases) || matchAny(t.value, m.packageNames(ws)), nil
+	case "path":
+		return matchGlob(t.value, filepath.ToSlash(ws.RelativePath(m.root))), nil
+	case "depth":
+		return compare(t.op, ws.Depth, t.depth), nil
+	case "owner":
+		m.describe(ws, workspace.DetailOwners)
+		return matchAny(t.value, ws.Owners), nil
+	case "tag":
+		m.describe(ws, workspace.DetailMetadata)
+		return matchAny(t.value, ws.Tags), nil
+	case "changed":
+		return m.isChanged(ws, t.value)
+	default:
+		return false, fmt.Errorf("unknown query key %q", t.key)
+	}
+}
+
+// describe fills in the details of ws a term needs. Invalid workspace files
+// are left to panama doctor and list --verbose to report.
+func (m *Matcher) describe(ws *workspace.Workspace, details workspace.Details) {
+	if m.describer != nil {
+		_ = m.describer.Describe(ws, details)
+	}
+}
+
+func (m *Matcher) packageNames(ws *workspace.Workspace) []string {
+	if names, ok := m.packages[ws.Path]; ok {
+		return names
+	}
+	var names []string
+	for _, mf := range manifest.Read(ws.Path) {
+		if mf.Name != "" {
+			names = append(names, mf.Name)
+		}
+	}
+	m.packages[ws.Path] = names
+	return names
+}
+
+// isChanged reports whether files in ws differ from ref, or from HEAD when
+// ref is empty. Untracked files count as changes.
+func (m *Matcher) isChanged(ws *workspace.Workspace, ref string) (bool, error) {
+	tree := m.workTree(ws.Path)
+	if tree == "" {
+		return false, nil
+	}
+
+	key := changeKey{tree, ref}
+	files, ok := m.changed[key]
+	if !ok {
+		var err error
+		if files, err = changes.Files(tree, ref); err != nil {
+			return false, fmt.Errorf("changed:%s: %w", ref, err)
+		}
+		m.changed[key] = files
+	}
+
+	rel, err := filepath.Rel(tree, ws.Path)
+	if err != nil {
+		return false, nil
+	}
+	if rel == "." {
+		return len(files) > 0, nil
+	}
+	prefix := filepath.ToSlash(rel) + "/"
+	for _, file := range files {
+		if strings.HasPrefix(file, prefix) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// workTree returns the nearest directory at or above dir containing .git
+func (m *Matcher) workTree(dir string) string {
+	if tree, ok := m.trees[dir]; ok {
+		return tree
+	}
+
+	tree := ""
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		tree = dir
+	} else if parent := filepath.Dir(dir); parent != dir {
+		tree = m.workTree(parent)
+	}
+	m.trees[dir] = tree
+	return tree
+}
@@ -0,0 +1,148 @@
+// Package query parses and evaluates workspace queries. A query mixes
+// structured terms such as type:go, depth:<3 or !legacy with free text that
+// is fuzzy matched against workspace labels.
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Keys lists the supported term keys
+var Keys = []string{"type", "name", "path", "depth", "owner", "tag", "changed"}
+
+// term is a single structured term
+type term struct {
+	key    string // One of Keys, or "" for a negated text term
+	value  string
+	negate bool
+	op     string // Comparison operator of depth terms
+	depth  int
+}
+
+// Query is a parsed query
+type Query struct {
+	terms []term
+	// Text holds the free-text terms, separated by spaces, to be fuzzy
+	// matched against workspace labels
+	Text string
+}
+
+// Parse parses s. Terms are separated by whitespace:
+//
+//	key:value   structured term; values of type, name, path, owner and tag
+//	            are globs
+//	depth:<3    depth comparison with <, <=, >, >= or =
+//	changed:    changed in git, or changed:REF for changes since REF
+//	!term       negation; !text excludes labels containing text
+//	text        fuzzy matched against the label
+func Parse(s string) (*Query, error) {
+	q := &Query{}
+	var text []string
+
+	for _, field := range strings.Fields(s) {
+		t := term{}
+		if rest, ok := strings.CutPrefix(field, "!"); ok && rest != "" {
+			t.negate = true
+			field = rest
+		}
+
+		key, value, structured := strings.Cut(field, ":")
+		if structured && !slices.Contains(Keys, key) {
+			return nil, fmt.Errorf("unknown query key %q (must be one of: %s)", key, strings.Join(Keys, ", "))
+		}
+
+		switch {
+		case !structured && !t.negate:
+			text = append(text, field)
+			continue
+		case !structured:
+			t.value = strings.ToLower(field)
+		case key == "depth":
+			op, depth, err := parseDepth(value)
+			if err != nil {
+				return nil, err
+			}
+			t.key, t.op, t.depth = key, op, depth
+		case key == "changed":
+			t.key, t.value = key, value
+		case value == "":
+			return nil, fmt.Errorf("missing value for %s:", key)
+		default:
+			if !doublestar.ValidatePattern(value) {
+				return nil, fmt.Errorf("invalid pattern in %s:%s", key, value)
+			}
+			t.key, t.value = key, value
+		}
+		q.terms = append(q.terms, t)
+	}
+
+	q.Text = strings.Join(text, " ")
+	return q, nil
+}
+
+func parseDepth(value string) (string, int, error) {
+	op := strings.TrimRightFunc(value, unicode.IsDigit)
+	switch op {
+	case "", "=":
+		op = "="
+	case "<", "<=", ">", ">=":
+	default:
+		return "", 0, fmt.Errorf("invalid depth comparison %q", value)
+	}
+	depth, err := strconv.Atoi(strings.TrimLeft(value, "<>="))
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid depth %q", value)
+	}
+	return op, depth, nil
+}
+
+// Structured reports whether q has structured or negated terms
+func (q *Query) Structured() bool {
+	return len(q.terms) > 0
+}
+
+// Refs returns the git references used by changed: terms, with "" for the
+// working tree
+func (q *Query) Refs() []string {
+	var refs []string
+	for _, t := range q.terms {
+		if t.key == "changed" && !slices.Contains(refs, t.value) {
+			refs = append(refs, t.value)
+		}
+	}
+	return refs
+}
+
+func compare(op string, a, b int) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	default:
+		return a == b
+	}
+}
+
+// matchGlob matches value against pattern, ignoring case. ** matches
+// across path separators.
+func matchGlob(pattern, value string) bool {
+	matched, _ := doublestar.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return matched
+}
+
+func matchAny(pattern string, values []string) bool {
+	return slices.ContainsFunc(values, func(v string) bool {
+		return matchGlob(pattern, v)
+	})
+}
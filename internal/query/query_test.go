@@ -0,0 +1,249 @@
+package query
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/yuya-takeyama/panama/internal/match"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantText  string
+		wantTerms int
+		wantErr   bool
+	}{
+		{input: "", wantText: ""},
+		{input: "api web", wantText: "api web"},
+		{input: "type:go api", wantText: "api", wantTerms: 1},
+		{input: "!legacy depth:<3 name:@acme/*", wantTerms: 3},
+		{input: "changed: changed:origin/main", wantTerms: 2},
+		{input: "!", wantText: "!"},
+		{input: "color:red", wantErr: true},
+		{input: "type:", wantErr: true},
+		{input: "depth:~3", wantErr: true},
+		{input: "depth:<x", wantErr: true},
+		{input: "path:[", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			q, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if q.Text != tt.wantText {
+				t.Errorf("Text = %q, want %q", q.Text, tt.wantText)
+			}
+			if len(q.terms) != tt.wantTerms {
+				t.Errorf("got %d terms, want %d", len(q.terms), tt.wantTerms)
+			}
+		})
+	}
+}
+
+func TestRefs(t *testing.T) {
+	q, err := Parse("changed: changed:main changed:main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refs := q.Refs(); !slices.Equal(refs, []string{"", "main"}) {
+		t.Errorf("Refs() = %q", refs)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	root := t.TempDir()
+	web := filepath.Join(root, "apps", "web")
+	if err := os.MkdirAll(web, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(web, "package.json"), []byte(`{"name": "@acme/web"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	workspaces := []*workspace.Workspace{
+		{Path: web, Name: "web", Depth: 2, Type: "node", Owners: []string{"@acme/frontend"}},
+		{Path: filepath.Join(root, "services", "api"), Name: "api", Depth: 2, Type: "go", Owners: []string{"@acme/team-pay"}, Tags: []string{"backend"}, Aliases: []string{"gateway"}},
+		{Path: filepath.Join(root, "services", "legacy", "billing"), Name: "billing", Depth: 3, Type: "go", Tags: []string{"backend", "deprecated"}},
+		{Path: filepath.Join(root, "tools"), Name: "tools", Depth: 1},
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "", want: []string{"web", "api", "billing", "tools"}},
+		{query: "type:go", want: []string{"api", "billing"}},
+		{query: "type:GO !legacy", want: []string{"api"}},
+		{query: "!type:go", want: []string{"web", "tools"}},
+		{query: "name:@acme/*", want: []string{"web"}},
+		{query: "name:gate*", want: []string{"api"}},
+		{query: "path:services/**", want: []string{"api", "billing"}},
+		{query: "path:services/*", want: []string{"api"}},
+		{query: "depth:<3", want: []string{"web", "api", "tools"}},
+		{query: "depth:>=2 depth:2", want: []string{"web", "api"}},
+		{query: "owner:@acme/team-*", want: []string{"api"}},
+		{query: "tag:backend !tag:deprecated", want: []string{"api"}},
+		{query: "tag:backend bil", want: []string{"billing"}},
+		{query: "svc api", want: []string{"api"}},
+	}
+
+	m := NewMatcher(root, nil)
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			matched, err := m.Filter(q, workspaces, match.Fuzzy{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, ws := range matched {
+				names = append(names, ws.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("Filter(%q) = %v, want %v", tt.query, names, tt.want)
+			}
+		})
+	}
+}
+
+// prefixMatcher matches the labels starting with the pattern
+type prefixMatcher struct{}
+
+func (prefixMatcher) Match(pattern string, labels []string) []match.Result {
+	var results []match.Result
+	for i, label := range labels {
+		if strings.HasPrefix(label, pattern) {
+			results = append(results, match.Result{Idx: i})
+		}
+	}
+	return results
+}
+
+func TestFilter_TextMatcher(t *testing.T) {
+	root := t.TempDir()
+	workspaces := []*workspace.Workspace{
+		{Path: filepath.Join(root, "apps", "web"), Name: "web"},
+		{Path: filepath.Join(root, "services", "api"), Name: "api"},
+		{Path: filepath.Join(root, "tools"), Name: "tools"},
+	}
+
+	tests := []struct {
+		name    string
+		matcher match.Matcher
+		want    []string
+	}{
+		{name: "fuzzy", matcher: match.Fuzzy{}, want: []string{"web", "api", "tools"}},
+		{name: "configured", matcher: prefixMatcher{}, want: []string{"api"}},
+	}
+
+	q, err := Parse("s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMatcher(root, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, err := m.Filter(q, workspaces, tt.matcher)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, ws := range matched {
+				names = append(names, ws.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("Filter() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilter_Changed(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	write := func(name string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q", "-b", "main")
+	write("apps/web/index.js")
+	write("services/api/main.go")
+	write("services/billing/main.go")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+	git("checkout", "-q", "-b", "feature")
+	write("services/api/handler.go")
+	git("add", "-A")
+	git("commit", "-q", "-m", "handler")
+	write("apps/web/index.js")
+	write("apps/web/index.js.orig")
+
+	workspaces := []*workspace.Workspace{
+		{Path: filepath.Join(root, "apps", "web"), Name: "web"},
+		{Path: filepath.Join(root, "services", "api"), Name: "api"},
+		{Path: filepath.Join(root, "services", "billing"), Name: "billing"},
+	}
+
+	tests := []struct {
+		query   string
+		want    []string
+		wantErr bool
+	}{
+		{query: "changed:", want: []string{"web"}},
+		{query: "changed:main", want: []string{"web", "api"}},
+		{query: "!changed:main", want: []string{"billing"}},
+		{query: "changed:no-such-ref", wantErr: true},
+	}
+
+	m := NewMatcher(root, nil)
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			matched, err := m.Filter(q, workspaces, match.Fuzzy{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Filter(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+			var names []string
+			for _, ws := range matched {
+				names = append(names, ws.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("Filter(%q) = %v, want %v", tt.query, names, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Package rootdir finds the root of a project by looking for marker files in
+// a directory and its parents.
+package rootdir
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Options configures Find
+type Options struct {
+	Markers   []string              // Names of files or directories marking a root
+	Match     func(dir string) bool // Also marks dir as a root when it returns true
+	Outermost bool                  // Take the outermost root instead of the nearest
+	Ceilings  []string              // Absolute directories the search never climbs into
+}
+
+// Find returns the nearest, or outermost, directory at or above start that
+// holds one of the markers or satisfies Match. The search stops before
+// entering a ceiling directory, although start itself is always checked. It
+// returns an empty string when no root is found.
+func Find(start string, opts Options) string {
+	ceilings := make([]string, 0, len(opts.Ceilings))
+	for _, ceiling := range opts.Ceilings {
+		ceilings = append(ceilings, filepath.Clean(ceiling))
+	}
+
+	found := ""
+	dir := filepath.Clean(start)
+	for {
+		if isRoot(dir, opts) {
+			if !opts.Outermost {
+				return dir
+			}
+			found = dir
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir || slices.Contains(ceilings, parent) {
+			return found
+		}
+		dir = parent
+	}
+}
+
+func isRoot(dir string, opts Options) bool {
+	for _, marker := range opts.Markers {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+	return opts.Match != nil && opts.Match(dir)
+}
+
+// EnvCeilings returns the ceiling directories listed in
+// PANAMA_CEILING_DIRECTORIES and GIT_CEILING_DIRECTORIES, separated like
+// PATH. Relative entries are ignored, as git does.
+func EnvCeilings() []string {
+	var ceilings []string
+	for _, name := range []string{"PANAMA_CEILING_DIRECTORIES", "GIT_CEILING_DIRECTORIES"} {
+		for _, dir := range strings.Split(os.Getenv(name), string(os.PathListSeparator)) {
+			if filepath.IsAbs(dir) {
+				ceilings = append(ceilings, dir)
+			}
+		}
+	}
+	return ceilings
+}
@@ -0,0 +1,102 @@
+package rootdir
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	home := t.TempDir()
+	for _, path := range []string{
+		".git/",
+		"work/monorepo/.git/",
+		"work/monorepo/go.work",
+		"work/monorepo/services/api/go.work",
+		"work/monorepo/services/api/internal/handlers/",
+		"work/scratch/notes/",
+	} {
+		full := filepath.Join(home, filepath.FromSlash(path))
+		if strings.HasSuffix(path, "/") {
+			if err := os.MkdirAll(full, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := func(rel string) string { return filepath.Join(home, filepath.FromSlash(rel)) }
+	handlers := dir("work/monorepo/services/api/internal/handlers")
+
+	tests := []struct {
+		name  string
+		start string
+		opts  Options
+		want  string
+	}{
+		{
+			name:  "nearest marker",
+			start: handlers,
+			opts:  Options{Markers: []string{".git", "go.work"}},
+			want:  dir("work/monorepo/services/api"),
+		},
+		{
+			name:  "outermost marker",
+			start: handlers,
+			opts:  Options{Markers: []string{".git", "go.work"}, Outermost: true},
+			want:  home,
+		},
+		{
+			name:  "ceiling is not entered",
+			start: handlers,
+			opts:  Options{Markers: []string{".git", "go.work"}, Outermost: true, Ceilings: []string{home}},
+			want:  dir("work/monorepo"),
+		},
+		{
+			name:  "start is checked even at a ceiling",
+			start: home,
+			opts:  Options{Markers: []string{".git"}, Ceilings: []string{home}},
+			want:  home,
+		},
+		{
+			name:  "nothing below the ceiling",
+			start: dir("work/scratch/notes"),
+			opts:  Options{Markers: []string{".git"}, Ceilings: []string{home}},
+			want:  "",
+		},
+		{
+			name:  "match function",
+			start: handlers,
+			opts: Options{Match: func(d string) bool {
+				return filepath.Base(d) == "internal"
+			}},
+			want: dir("work/monorepo/services/api/internal"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Find(tt.start, tt.opts); got != tt.want {
+				t.Errorf("Find() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvCeilings(t *testing.T) {
+	sep := string(os.PathListSeparator)
+	a, b := filepath.Join(t.TempDir(), "a"), filepath.Join(t.TempDir(), "b")
+	t.Setenv("PANAMA_CEILING_DIRECTORIES", a+sep+"relative")
+	t.Setenv("GIT_CEILING_DIRECTORIES", b)
+
+	if got, want := EnvCeilings(), []string{a, b}; !slices.Equal(got, want) {
+		t.Errorf("EnvCeilings() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Output interleaves the output of parallel jobs line by line, so lines of
+// different workspaces never mix
+type Output struct {
+	mu     sync.Mutex
+	stdout io.Writer
+	stderr io.Writer
+	width  int // Width the names are padded to
+}
+
+// NewOutput returns an Output writing to stdout and stderr, padding names
+// to the longest of names
+func NewOutput(stdout, stderr io.Writer, names []string) *Output {
+	o := &Output{stdout: stdout, stderr: stderr}
+	for _, name := range names {
+		o.width = max(o.width, len(name))
+	}
+	return o
+}
+
+// Writers returns the writers for the output of the job called name
+func (o *Output) Writers(name string) (stdout, stderr *LineWriter) {
+	prefix := fmt.Sprintf("%-*s | ", o.width, name)
+	return &LineWriter{out: o, w: o.stdout, prefix: prefix}, &LineWriter{out: o, w: o.stderr, prefix: prefix}
+}
+
+// LineWriter prefixes each complete line written to it
+type LineWriter struct {
+	out    *Output
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (l *LineWriter) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+		l.writeLine(l.buf[:i+1])
+		l.buf = l.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes a trailing line without a newline
+func (l *LineWriter) Flush() {
+	if len(l.buf) > 0 {
+		l.writeLine(append(l.buf, '\n'))
+		l.buf = nil
+	}
+}
+
+func (l *LineWriter) writeLine(line []byte) {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	fmt.Fprint(l.w, l.prefix)
+	l.w.Write(line)
+}
@@ -0,0 +1,175 @@
+// Package runner runs a command in many workspaces in parallel, prefixing
+// each line of output with the workspace name.
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuya-takeyama/panama/internal/shell"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// Status is the outcome of a job
+type Status string
+
+const (
+	StatusOK        Status = "ok"
+	StatusFailed    Status = "failed"
+	StatusSkipped   Status = "skipped"   // Not started after a failure with FailFast, or of a job it needs
+	StatusCancelled Status = "cancelled" // Stopped after a failure with FailFast
+)
+
+// Job is a command to run in a workspace
+type Job struct {
+	Workspace *workspace.Workspace
+	// Command runs through the shell when it has a single element, and is
+	// executed directly otherwise
+	Command []string
+	Env     []string // Added to the environment of the command
+	// Needs holds the indices of earlier jobs that must succeed before this
+	// one starts
+	Needs []int
+}
+
+// Result is the outcome of a job
+type Result struct {
+	Name     string        `json:"name"`
+	Path     string        `json:"path"`
+	Status   Status        `json:"status"`
+	ExitCode int           `json:"exit_code"`
+	Duration time.Duration `json:"-"`
+	Error    string        `json:"error,omitempty"` // Why the command could not run
+}
+
+// Options configures Run
+type Options struct {
+	Parallel int  // Jobs run at once; values below 1 mean 1
+	FailFast bool // Stop starting jobs and cancel running ones after a failure
+	Output   *Output
+}
+
+// Run runs the jobs and returns their results in job order
+func Run(ctx context.Context, jobs []Job, opts Options) []Result {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]Result, len(jobs))
+	for i, job := range jobs {
+		results[i] = Result{Name: job.Workspace.Name, Path: job.Workspace.Path, Status: StatusSkipped, ExitCode: -1}
+	}
+
+	sem := make(chan struct{}, max(opts.Parallel, 1))
+	done := make([]chan struct{}, len(jobs))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		if failed := failedNeed(job, i, results, done); failed != "" {
+			results[i].Error = "needs " + failed + ", which did not succeed"
+			close(done[i])
+			continue
+		}
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[i])
+			defer func() { <-sem }()
+			results[i] = run(ctx, job, opts.Output)
+			if results[i].Status == StatusFailed && opts.FailFast {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// failedNeed waits for the jobs needed by the job at index i and returns
+// the name of the first one that did not succeed
+func failedNeed(job Job, i int, results []Result, done []chan struct{}) string {
+	for _, need := range job.Needs {
+		if need < 0 || need >= i {
+			continue
+		}
+		<-done[need]
+		if results[need].Status != StatusOK {
+			return results[need].Name
+		}
+	}
+	return ""
+}
+
+func run(ctx context.Context, job Job, output *Output) Result {
+	ws := job.Workspace
+	result := Result{Name: ws.Name, Path: ws.Path}
+
+	var cmd *exec.Cmd
+	if len(job.Command) == 1 {
+		cmd = shell.Command(ctx, job.Command[0])
+	} else {
+		cmd = exec.CommandContext(ctx, job.Command[0], job.Command[1:]...)
+	}
+	cmd.Dir = ws.Path
+	cmd.Env = append(append(os.Environ(), "PANAMA_WORKSPACE="+ws.Path), job.Env...)
+	stdout, stderr := output.Writers(ws.Name)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	result.Duration = time.Since(start)
+	stdout.Flush()
+	stderr.Flush()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		result.Status = StatusOK
+	case ctx.Err() != nil:
+		result.Status = StatusCancelled
+		result.ExitCode = -1
+	case errors.As(err, &exitErr):
+		result.Status = StatusFailed
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		result.Status = StatusFailed
+		result.ExitCode = -1
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Summary returns an error describing the failures, or nil when every job
+// succeeded
+func Summary(results []Result) error {
+	var failed []string
+	stopped := 0
+	for _, r := range results {
+		switch r.Status {
+		case StatusFailed:
+			failed = append(failed, r.Name)
+		case StatusSkipped, StatusCancelled:
+			stopped++
+		}
+	}
+	if len(failed) == 0 && stopped == 0 {
+		return nil
+	}
+	err := fmt.Sprintf("failed in %d of %d workspaces: %s", len(failed), len(results), strings.Join(failed, ", "))
+	if stopped > 0 {
+		err += fmt.Sprintf(" (%d skipped or cancelled)", stopped)
+	}
+	return errors.New(err)
+}
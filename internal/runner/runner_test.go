@@ -0,0 +1,113 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+func setupJobs(t *testing.T, command []string, names ...string) []Job {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	root := t.TempDir()
+	jobs := make([]Job, len(names))
+	for i, name := range names {
+		dir := filepath.Join(root, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		jobs[i] = Job{Workspace: &workspace.Workspace{Name: name, Path: dir}, Command: command}
+	}
+	return jobs
+}
+
+func statuses(results []Result) []Status {
+	s := make([]Status, len(results))
+	for i, r := range results {
+		s[i] = r.Status
+	}
+	return s
+}
+
+func TestRun(t *testing.T) {
+	jobs := setupJobs(t, []string{`printf 'one\ntwo'; basename "$PANAMA_WORKSPACE" >&2; test "$(basename "$PWD")" != bad`}, "api", "bad", "web")
+
+	var stdout, stderr bytes.Buffer
+	results := Run(context.Background(), jobs, Options{
+		Parallel: 2,
+		Output:   NewOutput(&stdout, &stderr, []string{"api", "bad", "web"}),
+	})
+
+	if got, want := statuses(results), []Status{StatusOK, StatusFailed, StatusOK}; !slices.Equal(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+	if results[1].ExitCode != 1 {
+		t.Errorf("exit code = %d, want 1", results[1].ExitCode)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	slices.Sort(lines)
+	want := []string{"api | one", "api | two", "bad | one", "bad | two", "web | one", "web | two"}
+	if !slices.Equal(lines, want) {
+		t.Errorf("stdout lines = %q, want %q", lines, want)
+	}
+	if !strings.Contains(stderr.String(), "web | web\n") {
+		t.Errorf("stderr = %q, want prefixed lines", stderr.String())
+	}
+
+	if err := Summary(results); err == nil || !strings.Contains(err.Error(), "failed in 1 of 3 workspaces: bad") {
+		t.Errorf("Summary() = %v", err)
+	}
+}
+
+func TestRun_Args(t *testing.T) {
+	jobs := setupJobs(t, []string{"sh", "-c", `test "$1" = "a b"`, "sh", "a b"}, "api")
+	var out bytes.Buffer
+	results := Run(context.Background(), jobs, Options{Output: NewOutput(&out, &out, nil)})
+	if results[0].Status != StatusOK {
+		t.Errorf("arguments were not passed as is: %+v", results[0])
+	}
+	if err := Summary(results); err != nil {
+		t.Errorf("Summary() = %v", err)
+	}
+}
+
+func TestRun_FailFast(t *testing.T) {
+	jobs := setupJobs(t, []string{`test "$(basename "$PWD")" != a`}, "a", "b", "c")
+	var out bytes.Buffer
+	results := Run(context.Background(), jobs, Options{Parallel: 1, FailFast: true, Output: NewOutput(&out, &out, nil)})
+
+	if got, want := statuses(results), []Status{StatusFailed, StatusSkipped, StatusSkipped}; !slices.Equal(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+}
+
+func TestRun_Needs(t *testing.T) {
+	// b needs the failing a, d needs c, which writes the file d reads
+	jobs := setupJobs(t, []string{`case "$(basename "$PWD")" in
+a) exit 1 ;;
+c) sleep 0.2; touch ../c.done ;;
+d) test -f ../c.done ;;
+esac`}, "a", "b", "c", "d")
+	jobs[1].Needs = []int{0}
+	jobs[3].Needs = []int{2}
+
+	var out bytes.Buffer
+	results := Run(context.Background(), jobs, Options{Parallel: 4, Output: NewOutput(&out, &out, nil)})
+
+	if got, want := statuses(results), []Status{StatusFailed, StatusSkipped, StatusOK, StatusOK}; !slices.Equal(got, want) {
+		t.Errorf("statuses = %v, want %v (%+v)", got, want, results)
+	}
+	if !strings.Contains(results[1].Error, "needs a") {
+		t.Errorf("error = %q, want it to name a", results[1].Error)
+	}
+}
@@ -0,0 +1,29 @@
+package shell
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Command returns a command running command through the system shell
+func Command(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// Quote quotes s for use as a single shell word
+func Quote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + s + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Expand replaces every {} in command with the quoted path
+func Expand(command, path string) string {
+	return strings.ReplaceAll(command, "{}", Quote(path))
+}
@@ -0,0 +1,299 @@
+// Package tasks discovers the scripts, targets and recipes a workspace
+// defines in package.json, Makefiles, justfiles, Taskfiles and
+// pyproject.toml.
+package tasks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/goccy/go-yaml"
+	"github.com/yuya-takeyama/panama/internal/shell"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+// finder returns the tasks defined in the file at path
+type finder func(dir, path string) []workspace.Task
+
+var finders = []struct {
+	files []string // Alternative names of the file, the first found is read
+	find  finder
+}{
+	{[]string{"package.json"}, packageScripts},
+	{[]string{"Makefile", "makefile", "GNUmakefile"}, makeTargets},
+	{[]string{"justfile", "Justfile", ".justfile"}, justRecipes},
+	{[]string{"Taskfile.yml", "Taskfile.yaml", "taskfile.yml", "taskfile.yaml"}, taskfileTasks},
+	{[]string{"pyproject.toml"}, pyprojectScripts},
+}
+
+// Discover returns the tasks defined in dir, grouped by file in a fixed
+// order and in the order each file declares them
+func Discover(dir string) []workspace.Task {
+	var tasks []workspace.Task
+	for _, f := range finders {
+		for _, file := range f.files {
+			path := filepath.Join(dir, file)
+			if info, err := os.Stat(path); err != nil || info.IsDir() {
+				continue
+			}
+			tasks = append(tasks, unique(f.find(dir, path))...)
+			break
+		}
+	}
+	return tasks
+}
+
+// unique drops tasks whose name appeared before
+func unique(tasks []workspace.Task) []workspace.Task {
+	seen := make(map[string]bool, len(tasks))
+	return slices.DeleteFunc(tasks, func(t workspace.Task) bool {
+		if seen[t.Name] {
+			return true
+		}
+		seen[t.Name] = true
+		return false
+	})
+}
+
+// plainWord matches names the shell reads as a single word without quoting
+var plainWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// quote returns name as a single shell word, quoting it only when needed
+func quote(name string) string {
+	if plainWord.MatchString(name) {
+		return name
+	}
+	return shell.Quote(name)
+}
+
+// packageScripts returns the scripts of package.json, run with the package
+// manager the project uses
+func packageScripts(dir, path string) []workspace.Task {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		PackageManager string          `json:"packageManager"`
+		Scripts        json.RawMessage `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil || len(pkg.Scripts) == 0 {
+		return nil
+	}
+
+	manager, _, _ := strings.Cut(pkg.PackageManager, "@")
+	if manager == "" {
+		manager = nodePackageManager(dir)
+	}
+
+	// Decode the scripts one by one to keep their order
+	var tasks []workspace.Task
+	decoder := json.NewDecoder(bytes.NewReader(pkg.Scripts))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return tasks
+		}
+		var script any
+		if err := decoder.Decode(&script); err != nil {
+			return tasks
+		}
+		name, _ := key.(string)
+		tasks = append(tasks, workspace.Task{Name: name, Source: "package.json", Command: manager + " run " + quote(name)})
+	}
+	return tasks
+}
+
+// nodePackageManager guesses the package manager from the lock file in dir
+// or the closest parent, defaulting to npm
+func nodePackageManager(dir string) string {
+	managers := map[string]string{
+		"pnpm-lock.yaml":    "pnpm",
+		"yarn.lock":         "yarn",
+		"bun.lock":          "bun",
+		"bun.lockb":         "bun",
+		"package-lock.json": "npm",
+	}
+	if lock := findUp(dir, "pnpm-lock.yaml", "yarn.lock", "bun.lock", "bun.lockb", "package-lock.json"); lock != "" {
+		return managers[lock]
+	}
+	return "npm"
+}
+
+// findUp returns the first of names found in dir or its parents, stopping
+// at the root of the repository
+func findUp(dir string, names ...string) string {
+	for {
+		for _, name := range names {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return name
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// makeRule matches the targets of a rule, or the name of a := assignment
+var makeRule = regexp.MustCompile(`^([^\s:#=][^:#=]*?)\s*(:+=?)`)
+
+// makeTargets returns the explicit targets of a Makefile, leaving out
+// special targets such as .PHONY and pattern rules
+func makeTargets(dir, path string) []workspace.Task {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var tasks []workspace.Task
+	inDefine := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "define "):
+			inDefine = true
+			continue
+		case strings.HasPrefix(line, "endef"):
+			inDefine = false
+			continue
+		case inDefine:
+			continue
+		}
+
+		m := makeRule.FindStringSubmatch(line)
+		if m == nil || strings.HasSuffix(m[2], "=") {
+			continue
+		}
+		for _, target := range strings.Fields(m[1]) {
+			if strings.HasPrefix(target, ".") || strings.ContainsAny(target, "%$()") {
+				continue
+			}
+			tasks = append(tasks, workspace.Task{Name: target, Source: filepath.Base(path), Command: "make " + quote(target)})
+		}
+	}
+	return tasks
+}
+
+// justRecipe matches the name of a recipe, but not assignments
+var justRecipe = regexp.MustCompile(`^@?([A-Za-z_][A-Za-z0-9_-]*)(?:\s[^:]*)?:(?:[^=]|$)`)
+
+// justRecipes returns the public recipes of a justfile
+func justRecipes(dir, path string) []workspace.Task {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var tasks []workspace.Task
+	private := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "[") {
+			// Attributes apply to the recipe that follows
+			private = private || strings.Contains(line, "private")
+			continue
+		}
+		m := justRecipe.FindStringSubmatch(line)
+		if m == nil {
+			if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "#") {
+				private = false
+			}
+			continue
+		}
+		if name := m[1]; !private && !strings.HasPrefix(name, "_") {
+			tasks = append(tasks, workspace.Task{Name: name, Source: filepath.Base(path), Command: "just " + quote(name)})
+		}
+		private = false
+	}
+	return tasks
+}
+
+// taskfileTasks returns the tasks of a Taskfile that are not internal
+func taskfileTasks(dir, path string) []workspace.Task {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var taskfile struct {
+		Tasks yaml.MapSlice `yaml:"tasks"`
+	}
+	if err := yaml.Unmarshal(data, &taskfile); err != nil {
+		return nil
+	}
+
+	var tasks []workspace.Task
+	for _, item := range taskfile.Tasks {
+		name, ok := item.Key.(string)
+		if !ok {
+			continue
+		}
+		if spec, ok := item.Value.(map[string]any); ok && spec["internal"] == true {
+			continue
+		}
+		tasks = append(tasks, workspace.Task{Name: name, Source: filepath.Base(path), Command: "task " + quote(name)})
+	}
+	return tasks
+}
+
+// pyprojectScripts returns the scripts of pyproject.toml: the entry points
+// of [project.scripts] and [tool.poetry.scripts], and [tool.pdm.scripts]
+func pyprojectScripts(dir, path string) []workspace.Task {
+	var pyproject map[string]any
+	meta, err := toml.DecodeFile(path, &pyproject)
+	if err != nil {
+		return nil
+	}
+
+	// Entry points need the project environment, so run them through the
+	// tool managing it when there is one
+	entryPoint := ""
+	switch findUp(dir, "uv.lock", "poetry.lock", "pdm.lock") {
+	case "uv.lock":
+		entryPoint = "uv run "
+	case "poetry.lock":
+		entryPoint = "poetry run "
+	case "pdm.lock":
+		entryPoint = "pdm run "
+	}
+
+	var tasks []workspace.Task
+	for _, key := range meta.Keys() {
+		var name, command string
+		switch {
+		case len(key) == 3 && key[0] == "project" && key[1] == "scripts":
+			name, command = key[2], entryPoint+quote(key[2])
+		case len(key) == 4 && key[0] == "tool" && key[1] == "poetry" && key[2] == "scripts":
+			name, command = key[3], "poetry run "+quote(key[3])
+		case len(key) == 4 && key[0] == "tool" && key[1] == "pdm" && key[2] == "scripts":
+			// "_" holds options shared by the scripts
+			if key[3] == "_" {
+				continue
+			}
+			name, command = key[3], "pdm run "+quote(key[3])
+		default:
+			continue
+		}
+		tasks = append(tasks, workspace.Task{Name: name, Source: "pyproject.toml", Command: command})
+	}
+	return tasks
+}
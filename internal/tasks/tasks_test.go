@@ -0,0 +1,186 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/yuya-takeyama/panama/internal/shell"
+	"github.com/yuya-takeyama/panama/internal/workspace"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func commands(tasks []workspace.Task) []string {
+	out := make([]string, len(tasks))
+	for i, task := range tasks {
+		out[i] = task.Source + ": " + task.Command
+	}
+	return out
+}
+
+func TestDiscover(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		dir   string
+		want  []string
+	}{
+		{
+			name: "package.json scripts in order",
+			files: map[string]string{
+				"package.json": `{"name": "web", "scripts": {"test": "vitest", "build": "vite build", "test": "jest"}}`,
+			},
+			want: []string{"package.json: npm run test", "package.json: npm run build"},
+		},
+		{
+			name: "names quoted for the shell",
+			files: map[string]string{
+				"package.json": `{"scripts": {"test:unit": "vitest", "lint; echo it's done": "eslint ."}}`,
+			},
+			want: []string{"package.json: npm run test:unit", "package.json: npm run " + shell.Quote("lint; echo it's done")},
+		},
+		{
+			name: "package manager from the parent lock file",
+			files: map[string]string{
+				".git/HEAD":              "",
+				"pnpm-lock.yaml":         "",
+				"apps/web/package.json":  `{"scripts": {"dev": "vite"}}`,
+				"apps/api/package.json":  `{"packageManager": "yarn@4.1.0", "scripts": {"dev": "node ."}}`,
+				"apps/api/unrelated.txt": "",
+			},
+			dir:  "apps/web",
+			want: []string{"package.json: pnpm run dev"},
+		},
+		{
+			name: "packageManager field",
+			files: map[string]string{
+				"pnpm-lock.yaml": "",
+				"package.json":   `{"packageManager": "yarn@4.1.0", "scripts": {"dev": "node ."}}`,
+			},
+			want: []string{"package.json: yarn run dev"},
+		},
+		{
+			name: "Makefile targets",
+			files: map[string]string{
+				"Makefile": `.PHONY: build test
+VERSION := 1.0
+IMAGE ?= app:latest
+CC ::= gcc
+
+build test: deps
+	go build ./...
+
+%.o: %.c
+	$(CC) -c $<
+
+define HELP
+usage: make
+endef
+
+lint::
+	golangci-lint run
+build:
+`,
+			},
+			want: []string{"Makefile: make build", "Makefile: make test", "Makefile: make lint"},
+		},
+		{
+			name: "justfile recipes",
+			files: map[string]string{
+				"justfile": `set shell := ["bash", "-c"]
+version := "1.0"
+alias b := build
+
+# Build everything
+build target="debug":
+    cargo build
+
+@test *args: build
+    cargo test {{args}}
+
+_helper:
+    echo hidden
+
+[private]
+secret:
+    echo hidden
+
+[linux]
+deploy:
+    ./deploy.sh
+`,
+			},
+			want: []string{"justfile: just build", "justfile: just test", "justfile: just deploy"},
+		},
+		{
+			name: "Taskfile tasks",
+			files: map[string]string{
+				"Taskfile.yml": `version: '3'
+tasks:
+  build:
+    cmds: [go build ./...]
+  setup:
+    internal: true
+    cmds: [go mod download]
+  test: go test ./...
+`,
+			},
+			want: []string{"Taskfile.yml: task build", "Taskfile.yml: task test"},
+		},
+		{
+			name: "pyproject scripts",
+			files: map[string]string{
+				"uv.lock": "",
+				"pyproject.toml": `[project]
+name = "api"
+
+[project.scripts]
+serve = "api.main:serve"
+
+[tool.pdm.scripts]
+_ = {env_file = ".env"}
+lint = "ruff check ."
+test = {cmd = "pytest"}
+`,
+			},
+			want: []string{"pyproject.toml: uv run serve", "pyproject.toml: pdm run lint", "pyproject.toml: pdm run test"},
+		},
+		{
+			name: "several files",
+			files: map[string]string{
+				"package.json": `{"scripts": {"build": "tsc"}}`,
+				"Makefile":     "build:\n\tnpm run build\n",
+			},
+			want: []string{"package.json: npm run build", "Makefile: make build"},
+		},
+		{
+			name:  "no tasks",
+			files: map[string]string{"package.json": `{"name": "lib"}`, "go.mod": "module lib"},
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFiles(t, root, tt.files)
+			got := commands(Discover(filepath.Join(root, tt.dir)))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Discover() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+// Package finder selects between the built-in fuzzy finder and external
+// finders such as fzf.
+package finder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/yuya-takeyama/panama/internal/ui/fuzzyfinder"
+	"golang.org/x/term"
+)
+
+// Finder lets the user pick items interactively
+type Finder interface {
+	Find(items []fuzzyfinder.Item, opts fuzzyfinder.Options) (*fuzzyfinder.Result, error)
+}
+
+// New returns the finder called name: "builtin" (or empty) for the built-in
+// finder, or "fzf", "sk" or "peco" to run that executable with args appended
+// to its command line
+func New(name string, args []string) (Finder, error) {
+	if name == "" || name == "builtin" {
+		return Builtin{}, nil
+	}
+	f, ok := flavors[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown finder: %s", name)
+	}
+	return &External{Command: name, Args: args, flavor: f}, nil
+}
+
+// Builtin is the finder shipped with panama
+type Builtin struct{}
+
+func (Builtin) Find(items []fuzzyfinder.Item, opts fuzzyfinder.Options) (*fuzzyfinder.Result, error) {
+	return fuzzyfinder.Find(items, opts)
+}
+
+// SupportsKeys reports whether f ends the session on the keys of
+// Options.Keys and reports the key pressed
+func SupportsKeys(f Finder) bool {
+	e, ok := f.(*External)
+	return !ok || e.flavor.expect
+}
+
+// flavor describes the command-line interface of an external finder
+type flavor struct {
+	fields bool // Supports --delimiter and --with-nth, so paths can travel with labels
+	expect bool // Supports --expect and --print-query, so bound keys work
+	header bool // Supports --header
+	multi  string
+}
+
+var flavors = map[string]flavor{
+	"fzf":  {fields: true, expect: true, header: true, multi: "--multi"},
+	"sk":   {fields: true, expect: true, header: true, multi: "--multi"},
+	"peco": {}, // Always allows multiple selections with Ctrl+Space
+}
+
+// isTerminal reports whether stdin is a terminal; tests replace it
+var isTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// External runs an external finder. Candidates are written to its stdin,
+// and the lines it prints are mapped back to the items.
+type External struct {
+	Command string   // Executable name or path
+	Args    []string // Options passed through from the configuration
+	flavor  flavor
+}
+
+func (e *External) Find(items []fuzzyfinder.Item, opts fuzzyfinder.Options) (*fuzzyfinder.Result, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no items to select from")
+	}
+
+	if !isTerminal() {
+		return fuzzyfinder.NonInteractive(items, opts)
+	}
+
+	// None of the external finders can start with entries selected
+	if opts.Multi && opts.Preselected != nil {
+		for i := range items {
+			if opts.Preselected(i) {
+				return nil, fmt.Errorf("%s cannot preselect entries; use the builtin finder", e.Command)
+			}
+		}
+	}
+
+	// External finders only fuzzy match, so structured terms of the initial
+	// query narrow down the candidates before they are written out
+	if opts.Filter != nil && opts.Query != "" {
+		keep, text, err := opts.Filter(opts.Query)
+		if err != nil {
+			return nil, err
+		}
+		if keep != nil {
+			return e.findFiltered(items, opts, keep, text)
+		}
+		opts.Query = text
+	}
+
+	// With field support, each line carries the path after a tab so that
+	// labels need not be unique and passthrough options can use {2}
+	var input bytes.Buffer
+	for _, item := range items {
+		if e.flavor.fields {
+			fmt.Fprintf(&input, "%s\t%s\n", item.Label, item.Path)
+		} else {
+			fmt.Fprintln(&input, item.Label)
+		}
+	}
+
+	args, err := e.args(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var output bytes.Buffer
+	cmd := exec.Command(e.Command, args...)
+	cmd.Stdin = &input
+	cmd.Stdout = &output
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			// Nothing matched, but a bound key may still have been pressed
+			if result, err := e.parse(items, opts, output.String()); err == nil && result.Key != "" {
+				return result, nil
+			}
+			return nil, fuzzyfinder.ErrAbort
+		}
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 130 {
+			// Cancelled by the user
+			return nil, fuzzyfinder.ErrAbort
+		}
+		return nil, fmt.Errorf("failed to run %s: %w", e.Command, err)
+	}
+
+	return e.parse(items, opts, output.String())
+}
+
+// findFiltered runs the finder on the items kept by the filter and maps the
+// result back to the full item list
+func (e *External) findFiltered(items []fuzzyfinder.Item, opts fuzzyfinder.Options, keep func(i int) bool, text string) (*fuzzyfinder.Result, error) {
+	var kept []fuzzyfinder.Item
+	var indices []int
+	for i, item := range items {
+		if keep(i) {
+			kept = append(kept, item)
+			indices = append(indices, i)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, fuzzyfinder.ErrAbort
+	}
+
+	opts.Query, opts.Filter = text, nil
+	if opts.Preselected != nil {
+		preselected := opts.Preselected
+		opts.Preselected = func(i int) bool { return preselected(indices[i]) }
+	}
+	result, err := e.Find(kept, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i, idx := range result.Indices {
+		result.Indices[i] = indices[idx]
+	}
+	return result, nil
+}
+
+// args returns the command line for a session with opts
+func (e *External) args(opts fuzzyfinder.Options) ([]string, error) {
+	var args []string
+	if e.flavor.fields {
+		args = append(args, "--delimiter", "\t", "--with-nth", "1")
+	}
+	if opts.Prompt != "" {
+		args = append(args, "--prompt", opts.Prompt)
+	}
+	if opts.Query != "" {
+		args = append(args, "--query", opts.Query)
+	}
+	if opts.Multi && e.flavor.multi != "" {
+		args = append(args, e.flavor.multi)
+	}
+	if opts.Header != "" && e.flavor.header {
+		args = append(args, "--header", opts.Header)
+	}
+	if len(opts.Keys) > 0 && !e.flavor.expect {
+		return nil, fmt.Errorf("%s does not report key presses, so %s cannot be bound; use fzf, sk or the builtin finder", e.Command, strings.Join(opts.Keys, ", "))
+	}
+	if e.flavor.expect {
+		args = append(args, "--print-query")
+		if len(opts.Keys) > 0 {
+			keys, err := expectKeys(opts.Keys)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, "--expect", keys)
+		}
+	}
+	return append(args, e.Args...), nil
+}
+
+// expectKeys returns the bindings as an --expect list. Bindings are
+// normalized first, so that Ctrl+T becomes ctrl-t; the canonical names are
+// fzf's except for backspace, which fzf and sk call bspace.
+func expectKeys(keys []string) (string, error) {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		name, err := fuzzyfinder.NormalizeKey(key)
+		if err != nil {
+			return "", fmt.Errorf("invalid key binding: %w", err)
+		}
+		names[i] = strings.Replace(name, "backspace", "bspace", 1)
+	}
+	return strings.Join(names, ","), nil
+}
+
+// parse maps the output of the finder back to the items
+func (e *External) parse(items []fuzzyfinder.Item, opts fuzzyfinder.Options, output string) (*fuzzyfinder.Result, error) {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	result := &fuzzyfinder.Result{Query: opts.Query}
+
+	if e.flavor.expect {
+		if len(lines) > 0 {
+			result.Query, lines = lines[0], lines[1:]
+		}
+		if len(opts.Keys) > 0 && len(lines) > 0 {
+			if lines[0] != "" {
+				key, err := fuzzyfinder.NormalizeKey(lines[0])
+				if err != nil {
+					return nil, fmt.Errorf("%s reported an unexpected key: %w", e.Command, err)
+				}
+				result.Key = key
+			}
+			lines = lines[1:]
+		}
+	}
+
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		idx := slices.IndexFunc(items, func(item fuzzyfinder.Item) bool {
+			if e.flavor.fields {
+				_, path, _ := strings.Cut(line, "\t")
+				return item.Path == path
+			}
+			return item.Label == line
+		})
+		if idx < 0 {
+			return nil, fmt.Errorf("%s returned an unknown entry: %q", e.Command, line)
+		}
+		if !slices.Contains(result.Indices, idx) {
+			result.Indices = append(result.Indices, idx)
+		}
+	}
+
+	if len(result.Indices) == 0 && result.Key == "" {
+		return nil, fuzzyfinder.ErrAbort
+	}
+
+	// Keep the order of the item list rather than the selection order
+	slices.Sort(result.Indices)
+	return result, nil
+}
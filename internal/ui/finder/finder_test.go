@@ -0,0 +1,247 @@
+package finder
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/yuya-takeyama/panama/internal/ui/fuzzyfinder"
+)
+
+// fakeFinder is a finder executable that records its arguments and input,
+// then prints $FAKE_OUTPUT and exits with $FAKE_EXIT
+const fakeFinder = `#!/bin/sh
+printf '%s\n' "$@" > "$FAKE_DIR/args"
+cat > "$FAKE_DIR/input"
+printf "$FAKE_OUTPUT"
+exit ${FAKE_EXIT:-0}
+`
+
+var testItems = []fuzzyfinder.Item{
+	{Label: "apps/web", Path: "/repo/apps/web"},
+	{Label: "apps/admin", Path: "/repo/apps/admin"},
+	{Label: "services/api", Path: "/repo/services/api"},
+}
+
+func setupFake(t *testing.T, output string, exit string) (string, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the finder")
+	}
+
+	dir := t.TempDir()
+	command := filepath.Join(dir, "finder")
+	if err := os.WriteFile(command, []byte(fakeFinder), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FAKE_DIR", dir)
+	t.Setenv("FAKE_OUTPUT", output)
+	t.Setenv("FAKE_EXIT", exit)
+
+	orig := isTerminal
+	isTerminal = func() bool { return true }
+	t.Cleanup(func() { isTerminal = orig })
+
+	return command, dir
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+}
+
+func TestExternal_FZF(t *testing.T) {
+	command, dir := setupFake(t, `ap\nctrl-e\napps/admin\t/repo/apps/admin\nservices/api\t/repo/services/api\n`, "0")
+
+	f := &External{Command: command, Args: []string{"--height", "40%"}, flavor: flavors["fzf"]}
+	result, err := f.Find(testItems, fuzzyfinder.Options{
+		Prompt: "workspaces > ",
+		Query:  "a",
+		Multi:  true,
+		Keys:   []string{"ctrl-e", "alt-o"},
+	})
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+
+	if !slices.Equal(result.Indices, []int{1, 2}) {
+		t.Errorf("Indices = %v, want [1 2]", result.Indices)
+	}
+	if result.Key != "ctrl-e" {
+		t.Errorf("Key = %q, want ctrl-e", result.Key)
+	}
+	if result.Query != "ap" {
+		t.Errorf("Query = %q, want ap", result.Query)
+	}
+
+	args := strings.Join(readLines(t, filepath.Join(dir, "args")), " ")
+	for _, want := range []string{"--with-nth 1", "--prompt workspaces > ", "--query a", "--multi", "--print-query", "--expect ctrl-e,alt-o", "--height 40%"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("args %q do not contain %q", args, want)
+		}
+	}
+
+	input := readLines(t, filepath.Join(dir, "input"))
+	if input[0] != "apps/web\t/repo/apps/web" {
+		t.Errorf("unexpected input line %q", input[0])
+	}
+}
+
+func TestExternal_ExpectKeys(t *testing.T) {
+	command, dir := setupFake(t, `\nctrl-t\napps/web\t/repo/apps/web\n`, "0")
+
+	f := &External{Command: command, flavor: flavors["fzf"]}
+	result, err := f.Find(testItems, fuzzyfinder.Options{Keys: []string{"Ctrl+T", "alt+Backspace", "F5"}})
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if result.Key != "ctrl-t" || !slices.Equal(result.Indices, []int{0}) {
+		t.Errorf("Find() = %+v, want ctrl-t on apps/web", result)
+	}
+
+	args := strings.Join(readLines(t, filepath.Join(dir, "args")), " ")
+	if want := "--expect ctrl-t,alt-bspace,f5"; !strings.Contains(args, want) {
+		t.Errorf("args %q do not contain %q", args, want)
+	}
+
+	if _, err := f.Find(testItems, fuzzyfinder.Options{Keys: []string{"ctrl-shift-x"}}); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
+
+func TestExternal_Peco(t *testing.T) {
+	command, dir := setupFake(t, `services/api\n`, "0")
+
+	f := &External{Command: command, flavor: flavors["peco"]}
+	result, err := f.Find(testItems, fuzzyfinder.Options{Query: "api"})
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+
+	if !slices.Equal(result.Indices, []int{2}) || result.Key != "" || result.Query != "api" {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	args := readLines(t, filepath.Join(dir, "args"))
+	if slices.Contains(args, "--expect") || slices.Contains(args, "--with-nth") {
+		t.Errorf("unsupported options passed to peco: %v", args)
+	}
+	if input := readLines(t, filepath.Join(dir, "input")); input[2] != "services/api" {
+		t.Errorf("unexpected input %v", input)
+	}
+}
+
+func TestExternal_Unsupported(t *testing.T) {
+	command, _ := setupFake(t, `apps/web\n`, "0")
+
+	peco := &External{Command: command, flavor: flavors["peco"]}
+	if _, err := peco.Find(testItems, fuzzyfinder.Options{Keys: []string{"ctrl-e"}}); err == nil || !strings.Contains(err.Error(), "ctrl-e cannot be bound") {
+		t.Errorf("Find() error = %v, want key bindings rejected", err)
+	}
+	if !SupportsKeys(Builtin{}) || !SupportsKeys(&External{flavor: flavors["sk"]}) || SupportsKeys(peco) {
+		t.Error("SupportsKeys() does not match the flavors")
+	}
+
+	fzf := &External{Command: command, flavor: flavors["fzf"]}
+	preselected := func(i int) bool { return i == 2 }
+	if _, err := fzf.Find(testItems, fuzzyfinder.Options{Multi: true, Preselected: preselected}); err == nil || !strings.Contains(err.Error(), "cannot preselect") {
+		t.Errorf("Find() error = %v, want preselection rejected", err)
+	}
+}
+
+func TestExternal_Filter(t *testing.T) {
+	command, dir := setupFake(t, `ad\napps/admin\t/repo/apps/admin\n`, "0")
+
+	f := &External{Command: command, flavor: flavors["fzf"]}
+	result, err := f.Find(testItems, fuzzyfinder.Options{
+		Query: "apps: ad",
+		Filter: func(query string) (func(int) bool, string, error) {
+			text, _ := strings.CutPrefix(query, "apps: ")
+			return func(i int) bool { return i < 2 }, text, nil
+		},
+		Preselected: func(i int) bool { return i == 1 },
+	})
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if !slices.Equal(result.Indices, []int{1}) {
+		t.Errorf("Indices = %v, want [1]", result.Indices)
+	}
+
+	if input := readLines(t, filepath.Join(dir, "input")); len(input) != 2 {
+		t.Errorf("expected the filtered items only, got %v", input)
+	}
+	if args := strings.Join(readLines(t, filepath.Join(dir, "args")), " "); !strings.Contains(args, "--query ad") {
+		t.Errorf("args %q do not contain the text query", args)
+	}
+}
+
+func TestExternal_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		exit    string
+		wantErr error
+	}{
+		{name: "cancelled", exit: "130", wantErr: fuzzyfinder.ErrAbort},
+		{name: "no match", exit: "1", wantErr: fuzzyfinder.ErrAbort},
+		{name: "nothing chosen", output: `query\n`, exit: "0", wantErr: fuzzyfinder.ErrAbort},
+		{name: "failure", exit: "2"},
+		{name: "unknown entry", output: `\nunknown\t/elsewhere\n`, exit: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, _ := setupFake(t, tt.output, tt.exit)
+			f := &External{Command: command, flavor: flavors["fzf"]}
+
+			_, err := f.Find(testItems, fuzzyfinder.Options{})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && errors.Is(err, fuzzyfinder.ErrAbort) {
+				t.Errorf("error = %v, want a failure", err)
+			}
+		})
+	}
+}
+
+func TestExternal_NonInteractive(t *testing.T) {
+	orig := isTerminal
+	isTerminal = func() bool { return false }
+	t.Cleanup(func() { isTerminal = orig })
+
+	f := &External{Command: "does-not-exist", flavor: flavors["fzf"]}
+	result, err := f.Find(testItems, fuzzyfinder.Options{})
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if !slices.Equal(result.Indices, []int{0}) {
+		t.Errorf("Indices = %v, want [0]", result.Indices)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, name := range []string{"", "builtin"} {
+		if f, err := New(name, nil); err != nil || f != (Builtin{}) {
+			t.Errorf("New(%q) = %v, %v; want the built-in finder", name, f, err)
+		}
+	}
+	if f, err := New("fzf", []string{"--reverse"}); err != nil || f.(*External).Command != "fzf" {
+		t.Errorf("New(fzf) = %v, %v", f, err)
+	}
+	if _, err := New("selecta", nil); err == nil {
+		t.Error("expected an error for an unknown finder")
+	}
+}
@@ -0,0 +1,583 @@
+package fuzzyfinder
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ktr0731/go-ansisgr"
+	"github.com/mattn/go-runewidth"
+	"github.com/yuya-takeyama/panama/internal/preview"
+)
+
+// finder is an interactive session on a terminal screen. The list grows
+// upwards from the prompt on the bottom line, with the preview pane on the
+// right half of the screen. When the items are grouped, the list reads top
+// down like a tree instead, each group under a header.
+type finder struct {
+	screen tcell.Screen
+	items  []Item
+	labels []string
+	words  []string // Keywords of each item, joined by spaces; nil until needed
+	opts   Options
+	keys   map[string]bool
+
+	query     []rune
+	caret     int           // Position of the caret in query
+	err       error         // Error from Options.Filter
+	matched   []int         // Indices of the items matching query, best first
+	positions map[int][]int // Matched label positions by item
+	rows      []row         // Lines of the list: the matched items and group headers
+	cursor    int           // Position of the highlighted line in rows
+	offset    int           // Position of the first visible line in rows
+	selected  map[int]bool  // Items selected in multi mode
+
+	grouped   bool            // Some items have a group
+	collapsed map[string]bool // Groups whose items are hidden
+
+	previewMu sync.Mutex
+	previews  map[previewKey]string // Rendered previews
+	pending   map[previewKey]bool   // Previews being rendered
+}
+
+// row is a line of the list
+type row struct {
+	idx   int    // Item index, or -1 for a group header
+	group string // Group of the item, or the group the header starts
+	count int    // Matched items in the group, for headers
+}
+
+type previewKey struct {
+	idx, width, height int
+}
+
+func newFinder(screen tcell.Screen, items []Item, opts Options) (*finder, error) {
+	f := &finder{
+		screen:    screen,
+		items:     items,
+		labels:    make([]string, len(items)),
+		opts:      opts,
+		keys:      make(map[string]bool, len(opts.Keys)),
+		query:     []rune(opts.Query),
+		selected:  make(map[int]bool),
+		positions: make(map[int][]int),
+		collapsed: make(map[string]bool),
+		previews:  make(map[previewKey]string),
+		pending:   make(map[previewKey]bool),
+	}
+	f.caret = len(f.query)
+
+	for i, item := range items {
+		f.labels[i] = item.Label
+		if item.Group != "" {
+			f.grouped = true
+		}
+	}
+
+	for _, key := range opts.Keys {
+		name, err := NormalizeKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key binding: %w", err)
+		}
+		f.keys[name] = true
+	}
+
+	f.filter()
+
+	if opts.Preselected != nil {
+		for i := range items {
+			if !opts.Preselected(i) {
+				continue
+			}
+			if opts.Multi {
+				f.selected[i] = true
+			} else if pos := slices.IndexFunc(f.rows, func(r row) bool { return r.idx == i }); pos >= 0 {
+				f.cursor = pos
+				break
+			}
+		}
+	}
+
+	return f, nil
+}
+
+// run handles key presses until the user accepts, aborts or presses a
+// bound key
+func (f *finder) run() (*Result, error) {
+	for {
+		f.draw()
+
+		switch ev := f.screen.PollEvent().(type) {
+		case nil:
+			return nil, ErrAbort
+		case *tcell.EventResize:
+			f.screen.Sync()
+		case *tcell.EventKey:
+			result, err := f.handleKey(ev)
+			if err != nil || result != nil {
+				return result, err
+			}
+		}
+	}
+}
+
+func (f *finder) handleKey(ev *tcell.EventKey) (*Result, error) {
+	name := keyName(ev)
+
+	// Bound keys take precedence over the default bindings. They end the
+	// session even when nothing matches, leaving Result.Indices empty.
+	if name != "" && f.keys[name] {
+		return f.result(name), nil
+	}
+
+	switch name {
+	case "":
+		f.insert(ev.Rune())
+	case "enter":
+		if len(f.rows) == 0 {
+			return nil, nil
+		}
+		if f.rows[f.cursor].idx < 0 {
+			f.fold()
+			return nil, nil
+		}
+		return f.result(""), nil
+	case "esc", "ctrl-c", "ctrl-d":
+		return nil, ErrAbort
+	case "backspace":
+		if f.caret > 0 {
+			f.query = slices.Delete(f.query, f.caret-1, f.caret)
+			f.caret--
+			f.filter()
+		}
+	case "del":
+		f.deleteForward()
+	case "ctrl-w":
+		start := f.caret
+		for start > 0 && unicode.IsSpace(f.query[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(f.query[start-1]) {
+			start--
+		}
+		f.query = slices.Delete(f.query, start, f.caret)
+		f.caret = start
+		f.filter()
+	case "ctrl-u":
+		f.query = slices.Delete(f.query, 0, f.caret)
+		f.caret = 0
+		f.filter()
+	case "ctrl-a", "home":
+		f.caret = 0
+	case "ctrl-e", "end":
+		f.caret = len(f.query)
+	case "ctrl-b", "left":
+		f.caret = max(f.caret-1, 0)
+	case "ctrl-f", "right":
+		f.caret = min(f.caret+1, len(f.query))
+	case "up", "ctrl-k", "ctrl-p":
+		f.move(f.up())
+	case "down", "ctrl-j", "ctrl-n":
+		f.move(-f.up())
+	case "pgup":
+		f.move(f.up() * f.listHeight())
+	case "pgdn":
+		f.move(-f.up() * f.listHeight())
+	case "tab":
+		f.toggle()
+		f.move(-f.up())
+	case "btab":
+		f.toggle()
+		f.move(f.up())
+	case "ctrl-o":
+		f.fold()
+	}
+	return nil, nil
+}
+
+func (f *finder) insert(r rune) {
+	f.query = slices.Insert(f.query, f.caret, r)
+	f.caret++
+	f.filter()
+}
+
+func (f *finder) deleteForward() {
+	if f.caret < len(f.query) {
+		f.query = slices.Delete(f.query, f.caret, f.caret+1)
+		f.filter()
+	}
+}
+
+func (f *finder) toggle() {
+	idx, ok := f.current()
+	if !f.opts.Multi || !ok {
+		return
+	}
+	if f.selected[idx] {
+		delete(f.selected, idx)
+	} else {
+		f.selected[idx] = true
+	}
+}
+
+// current returns the item under the cursor; ok is false on a group header
+// or when nothing matches
+func (f *finder) current() (idx int, ok bool) {
+	if len(f.rows) == 0 || f.rows[f.cursor].idx < 0 {
+		return 0, false
+	}
+	return f.rows[f.cursor].idx, true
+}
+
+// up returns the change of the cursor position moving up on the screen
+func (f *finder) up() int {
+	if f.grouped {
+		return -1
+	}
+	return 1
+}
+
+// move moves the cursor by delta rows
+func (f *finder) move(delta int) {
+	if len(f.rows) == 0 {
+		return
+	}
+	f.cursor = min(max(f.cursor+delta, 0), len(f.rows)-1)
+}
+
+// fold collapses or expands the group under the cursor, leaving the cursor
+// on its header
+func (f *finder) fold() {
+	if !f.grouped || len(f.rows) == 0 {
+		return
+	}
+	group := f.rows[f.cursor].group
+	f.collapsed[group] = !f.collapsed[group]
+	f.layout()
+	f.cursor = max(slices.IndexFunc(f.rows, func(r row) bool {
+		return r.idx < 0 && r.group == group
+	}), 0)
+}
+
+// keywords returns the keywords of each item, looked up the first time
+// they are needed
+func (f *finder) keywords() []string {
+	if f.words == nil {
+		f.words = joinKeywords(f.items)
+	}
+	return f.words
+}
+
+// filter matches the items against the current query
+func (f *finder) filter() {
+	f.matched = f.matched[:0]
+	clear(f.positions)
+
+	results, err := rank(f.labels, f.keywords, f.opts, string(f.query))
+	f.err = err
+	for _, r := range results {
+		f.matched = append(f.matched, r.Idx)
+		f.positions[r.Idx] = r.Positions
+	}
+	f.layout()
+	f.cursor = max(slices.IndexFunc(f.rows, func(r row) bool { return r.idx >= 0 }), 0)
+	f.offset = 0
+}
+
+// layout arranges the matched items in rows. Groups are ordered by their
+// best match and keep their header while filtering.
+func (f *finder) layout() {
+	f.rows = f.rows[:0]
+	if !f.grouped {
+		for _, idx := range f.matched {
+			f.rows = append(f.rows, row{idx: idx})
+		}
+		return
+	}
+
+	var groups []string
+	members := make(map[string][]int)
+	for _, idx := range f.matched {
+		group := f.items[idx].Group
+		if _, ok := members[group]; !ok {
+			groups = append(groups, group)
+		}
+		members[group] = append(members[group], idx)
+	}
+	for _, group := range groups {
+		f.rows = append(f.rows, row{idx: -1, group: group, count: len(members[group])})
+		if f.collapsed[group] {
+			continue
+		}
+		for _, idx := range members[group] {
+			f.rows = append(f.rows, row{idx: idx, group: group})
+		}
+	}
+	f.cursor = min(f.cursor, max(len(f.rows)-1, 0))
+}
+
+func (f *finder) result(key string) *Result {
+	result := &Result{Key: key, Query: string(f.query)}
+	if f.opts.Multi && len(f.selected) > 0 {
+		for i := range f.items {
+			if f.selected[i] {
+				result.Indices = append(result.Indices, i)
+			}
+		}
+	} else if idx, ok := f.current(); ok {
+		result.Indices = []int{idx}
+	}
+	return result
+}
+
+func (f *finder) hasPreview() bool {
+	return f.items[0].Preview != nil || f.items[0].Description != ""
+}
+
+// listHeight returns the number of rows available for items
+func (f *finder) listHeight() int {
+	_, height := f.screen.Size()
+	rows := height - 2 // prompt and counter
+	if f.opts.Header != "" {
+		rows--
+	}
+	return max(rows, 0)
+}
+
+func (f *finder) draw() {
+	f.screen.Clear()
+	width, height := f.screen.Size()
+
+	listWidth := width
+	if f.hasPreview() {
+		listWidth = width / 2
+	}
+
+	// Prompt
+	col := f.drawText(0, height-1, listWidth, f.opts.Prompt, tcell.StyleDefault.Foreground(tcell.ColorBlue))
+	f.drawText(col, height-1, listWidth, string(f.query), tcell.StyleDefault)
+	f.screen.ShowCursor(col+runewidth.StringWidth(string(f.query[:f.caret])), height-1)
+
+	// Counter and header
+	counter := fmt.Sprintf("  %d/%d", len(f.matched), len(f.items))
+	if f.opts.Multi {
+		counter += fmt.Sprintf(" (%d)", len(f.selected))
+	}
+	counterStyle := tcell.StyleDefault.Foreground(tcell.ColorYellow)
+	if f.err != nil {
+		counter = "  " + f.err.Error()
+		counterStyle = tcell.StyleDefault.Foreground(tcell.ColorRed)
+	}
+	f.drawText(0, height-2, listWidth, counter, counterStyle)
+	if f.opts.Header != "" {
+		f.drawText(0, height-3, listWidth, "  "+f.opts.Header, tcell.StyleDefault.Foreground(tcell.ColorGreen))
+	}
+
+	// Items, keeping the cursor visible
+	rows := f.listHeight()
+	if f.cursor < f.offset {
+		f.offset = f.cursor
+	} else if rows > 0 && f.cursor >= f.offset+rows {
+		f.offset = f.cursor - rows + 1
+	}
+	bottom := height - 3
+	if f.opts.Header != "" {
+		bottom--
+	}
+	for n := 0; n < rows && f.offset+n < len(f.rows); n++ {
+		pos := f.offset + n
+		r := f.rows[pos]
+		y := bottom - n
+		if f.grouped {
+			y = bottom - rows + 1 + n
+		}
+
+		style := tcell.StyleDefault
+		if pos == f.cursor {
+			style = style.Bold(true)
+			f.screen.SetContent(0, y, '>', nil, style.Foreground(tcell.ColorRed))
+		}
+		if r.idx < 0 {
+			marker := "▾"
+			if f.collapsed[r.group] {
+				marker = "▸"
+			}
+			f.drawText(2, y, listWidth, fmt.Sprintf("%s %s (%d)", marker, r.group, r.count), style.Foreground(tcell.ColorBlue))
+			continue
+		}
+		if f.selected[r.idx] {
+			f.screen.SetContent(1, y, '*', nil, style.Foreground(tcell.ColorPurple))
+		}
+		x := 2
+		if f.grouped {
+			x += 2 * (f.items[r.idx].Indent + 1)
+		}
+		f.drawLabel(x, y, listWidth, r.idx, style)
+	}
+
+	if f.hasPreview() {
+		f.drawPreview(listWidth, width, height)
+	}
+
+	f.screen.Show()
+}
+
+// drawText draws s from column x and returns the column after it
+func (f *finder) drawText(x, y, maxX int, s string, style tcell.Style) int {
+	for _, r := range s {
+		w := runewidth.RuneWidth(r)
+		if x+w > maxX {
+			break
+		}
+		f.screen.SetContent(x, y, r, nil, style)
+		x += w
+	}
+	return x
+}
+
+// drawLabel draws an item label, highlighting the characters matching the
+// query and truncating it with ".." when it does not fit
+func (f *finder) drawLabel(x, y, maxX int, idx int, style tcell.Style) {
+	label := f.labels[idx]
+	highlight := f.positions[idx]
+	truncated := runewidth.StringWidth(label) > maxX-x
+	for i, r := range []rune(label) {
+		w := runewidth.RuneWidth(r)
+		if truncated && x+w > maxX-2 {
+			f.drawText(x, y, maxX, "..", style)
+			return
+		}
+		s := style
+		if slices.Contains(highlight, i) {
+			s = s.Foreground(tcell.ColorGreen)
+		}
+		f.screen.SetContent(x, y, r, nil, s)
+		x += w
+	}
+}
+
+func (f *finder) drawPreview(left, width, height int) {
+	border := tcell.StyleDefault.Foreground(tcell.ColorGray)
+	right := width - 1
+	bottom := height - 1
+
+	for x := left; x <= right; x++ {
+		f.screen.SetContent(x, 0, '─', nil, border)
+		f.screen.SetContent(x, bottom, '─', nil, border)
+	}
+	for y := 0; y <= bottom; y++ {
+		f.screen.SetContent(left, y, '│', nil, border)
+		f.screen.SetContent(right, y, '│', nil, border)
+	}
+	f.screen.SetContent(left, 0, '┌', nil, border)
+	f.screen.SetContent(right, 0, '┐', nil, border)
+	f.screen.SetContent(left, bottom, '└', nil, border)
+	f.screen.SetContent(right, bottom, '┘', nil, border)
+
+	idx, ok := f.current()
+	if !ok {
+		return
+	}
+
+	innerWidth := right - left - 1 - 2 // borders and padding
+	innerHeight := bottom - 1
+	text := f.previewText(idx, innerWidth, innerHeight)
+
+	iter := ansisgr.NewIterator(preview.Wrap(text, innerWidth))
+	x, y := left+2, 1
+	for y < bottom {
+		r, sgr, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if r == '\n' {
+			x, y = left+2, y+1
+			continue
+		}
+		w := runewidth.RuneWidth(r)
+		if x+w > right-1 {
+			continue
+		}
+		f.screen.SetContent(x, y, r, nil, sgrStyle(sgr))
+		x += w
+	}
+}
+
+// previewText returns the preview of item idx. Previews are rendered in the
+// background so that moving the cursor never waits for a slow command; the
+// screen is redrawn when the preview is ready.
+func (f *finder) previewText(idx, width, height int) string {
+	item := f.items[idx]
+	if item.Preview == nil {
+		text := fmt.Sprintf("Path: %s\n\n", item.Path)
+		if item.Description != "" {
+			text += fmt.Sprintf("Description:\n%s", item.Description)
+		}
+		return text
+	}
+
+	key := previewKey{idx, width, height}
+	f.previewMu.Lock()
+	defer f.previewMu.Unlock()
+
+	if text, ok := f.previews[key]; ok {
+		return text
+	}
+	if !f.pending[key] {
+		f.pending[key] = true
+		go func() {
+			text := item.Preview(width, height)
+			f.previewMu.Lock()
+			f.previews[key] = text
+			delete(f.pending, key)
+			f.previewMu.Unlock()
+			_ = f.screen.PostEvent(tcell.NewEventInterrupt(nil))
+		}()
+	}
+	return "Loading..."
+}
+
+// sgrStyle converts an ANSI SGR style to a tcell style
+func sgrStyle(sgr ansisgr.Style) tcell.Style {
+	style := tcell.StyleDefault
+	if color, ok := sgr.Foreground(); ok {
+		style = style.Foreground(sgrColor(color, 30))
+	}
+	if color, ok := sgr.Background(); ok {
+		style = style.Background(sgrColor(color, 40))
+	}
+	return style.
+		Bold(sgr.Bold()).
+		Dim(sgr.Dim()).
+		Italic(sgr.Italic()).
+		Underline(sgr.Underline()).
+		Blink(sgr.Blink()).
+		Reverse(sgr.Reverse()).
+		StrikeThrough(sgr.Strikethrough())
+}
+
+// sgrColor converts an SGR color to a tcell color. base is 30 for
+// foregrounds and 40 for backgrounds; 16 colors carry their SGR code.
+func sgrColor(color ansisgr.Color, base int) tcell.Color {
+	switch color.Mode() {
+	case ansisgr.Mode16:
+		value := color.Value()
+		switch {
+		case value >= 100:
+			return tcell.PaletteColor(value - 100 + 8) // Bright background
+		case value >= 90:
+			return tcell.PaletteColor(value - 90 + 8) // Bright foreground
+		default:
+			return tcell.PaletteColor(value - base)
+		}
+	case ansisgr.Mode256:
+		return tcell.PaletteColor(color.Value())
+	case ansisgr.ModeRGB:
+		r, g, b := color.RGB()
+		return tcell.NewRGBColor(int32(r), int32(g), int32(b))
+	default:
+		return tcell.ColorDefault
+	}
+}
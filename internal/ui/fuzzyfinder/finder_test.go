@@ -0,0 +1,355 @@
+package fuzzyfinder
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/ktr0731/go-ansisgr"
+	"github.com/yuya-takeyama/panama/internal/match"
+)
+
+var testItems = []Item{
+	{Label: "apps/web", Path: "/repo/apps/web"},
+	{Label: "apps/admin", Path: "/repo/apps/admin"},
+	{Label: "services/api", Path: "/repo/services/api"},
+}
+
+// runFinder runs a finder session over testItems on a simulation screen
+// fed with keys
+func runFinder(t *testing.T, opts Options, keys ...*tcell.EventKey) (*Result, error) {
+	t.Helper()
+	return runFinderWith(t, testItems, opts, keys...)
+}
+
+func runFinderWith(t *testing.T, items []Item, opts Options, keys ...*tcell.EventKey) (*Result, error) {
+	t.Helper()
+
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer screen.Fini()
+	screen.SetSize(80, 24)
+
+	for _, key := range keys {
+		screen.InjectKey(key.Key(), key.Rune(), key.Modifiers())
+	}
+
+	f, err := newFinder(screen, items, opts)
+	if err != nil {
+		return nil, err
+	}
+	return f.run()
+}
+
+func typed(s string) []*tcell.EventKey {
+	keys := make([]*tcell.EventKey, 0, len(s))
+	for _, r := range s {
+		keys = append(keys, tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+	}
+	return keys
+}
+
+func key(k tcell.Key) *tcell.EventKey {
+	return tcell.NewEventKey(k, 0, tcell.ModNone)
+}
+
+// testFilter keeps the items under apps/ for queries starting with "apps:"
+// and rejects queries starting with "bad:"
+func testFilter(query string) (func(int) bool, string, error) {
+	if strings.HasPrefix(query, "bad:") {
+		return nil, "", errors.New("bad query")
+	}
+	if text, ok := strings.CutPrefix(query, "apps:"); ok {
+		return func(i int) bool { return strings.HasPrefix(testItems[i].Label, "apps/") }, text, nil
+	}
+	return nil, query, nil
+}
+
+func TestFinder(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        Options
+		keys        []*tcell.EventKey
+		wantIndices []int
+		wantKey     string
+		wantQuery   string
+		wantErr     error
+	}{
+		{
+			name:        "enter picks the first item",
+			keys:        []*tcell.EventKey{key(tcell.KeyEnter)},
+			wantIndices: []int{0},
+		},
+		{
+			name:        "query filters the list",
+			keys:        append(typed("api"), key(tcell.KeyEnter)),
+			wantIndices: []int{2},
+			wantQuery:   "api",
+		},
+		{
+			name:        "cursor moves up",
+			keys:        []*tcell.EventKey{key(tcell.KeyUp), key(tcell.KeyEnter)},
+			wantIndices: []int{1},
+		},
+		{
+			name:        "preselected item starts under the cursor",
+			opts:        Options{Preselected: func(i int) bool { return i == 2 }},
+			keys:        []*tcell.EventKey{key(tcell.KeyEnter)},
+			wantIndices: []int{2},
+		},
+		{
+			name:        "bound key ends the session",
+			opts:        Options{Keys: []string{"ctrl-e", "alt-o"}},
+			keys:        []*tcell.EventKey{key(tcell.KeyUp), key(tcell.KeyCtrlE)},
+			wantIndices: []int{1},
+			wantKey:     "ctrl-e",
+		},
+		{
+			name:        "bound alt key",
+			opts:        Options{Keys: []string{"alt-o"}},
+			keys:        []*tcell.EventKey{tcell.NewEventKey(tcell.KeyRune, 'o', tcell.ModAlt)},
+			wantIndices: []int{0},
+			wantKey:     "alt-o",
+		},
+		{
+			name:        "unbound ctrl-e moves the caret",
+			keys:        append(typed("web"), key(tcell.KeyCtrlA), key(tcell.KeyCtrlE), key(tcell.KeyBackspace2), key(tcell.KeyEnter)),
+			wantIndices: []int{0},
+			wantQuery:   "we",
+		},
+		{
+			name:        "tab selects several items",
+			opts:        Options{Multi: true},
+			keys:        []*tcell.EventKey{key(tcell.KeyUp), key(tcell.KeyUp), key(tcell.KeyBacktab), key(tcell.KeyDown), key(tcell.KeyDown), key(tcell.KeyTab), key(tcell.KeyEnter)},
+			wantIndices: []int{0, 2},
+		},
+		{
+			name:        "preselected items in multi mode",
+			opts:        Options{Multi: true, Preselected: func(i int) bool { return i != 1 }},
+			keys:        []*tcell.EventKey{key(tcell.KeyEnter)},
+			wantIndices: []int{0, 2},
+		},
+		{
+			name:        "filter restricts the items",
+			opts:        Options{Filter: testFilter},
+			keys:        append(typed("apps: mn"), key(tcell.KeyEnter)),
+			wantIndices: []int{1},
+			wantQuery:   "apps: mn",
+		},
+		{
+			name:        "filter error matches nothing",
+			opts:        Options{Filter: testFilter},
+			keys:        append(append(typed("bad:"), key(tcell.KeyEnter), key(tcell.KeyCtrlU)), key(tcell.KeyEnter)),
+			wantIndices: []int{0},
+		},
+		{
+			name:    "escape aborts",
+			keys:    []*tcell.EventKey{key(tcell.KeyEscape)},
+			wantErr: ErrAbort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := runFinder(t, tt.opts, tt.keys...)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(result.Indices, tt.wantIndices) {
+				t.Errorf("Indices = %v, want %v", result.Indices, tt.wantIndices)
+			}
+			if result.Key != tt.wantKey {
+				t.Errorf("Key = %q, want %q", result.Key, tt.wantKey)
+			}
+			if result.Query != tt.wantQuery {
+				t.Errorf("Query = %q, want %q", result.Query, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestFinder_Groups(t *testing.T) {
+	items := []Item{
+		{Label: "apps", Group: "apps"},
+		{Label: "apps/web", Group: "apps", Indent: 1},
+		{Label: "services/api", Group: "services"},
+		{Label: "services/billing", Group: "services"},
+	}
+	tests := []struct {
+		name        string
+		keys        []*tcell.EventKey
+		wantIndices []int
+		wantErr     error
+	}{
+		{
+			name:        "cursor starts on the first item",
+			keys:        []*tcell.EventKey{key(tcell.KeyEnter)},
+			wantIndices: []int{0},
+		},
+		{
+			name:        "down moves through the tree",
+			keys:        []*tcell.EventKey{key(tcell.KeyDown), key(tcell.KeyDown), key(tcell.KeyDown), key(tcell.KeyEnter)},
+			wantIndices: []int{2},
+		},
+		{
+			name:        "filtering keeps the group header",
+			keys:        append(typed("bill"), key(tcell.KeyUp), key(tcell.KeyCtrlO), key(tcell.KeyCtrlO), key(tcell.KeyDown), key(tcell.KeyEnter)),
+			wantIndices: []int{3},
+		},
+		{
+			name:        "collapsed groups are skipped",
+			keys:        []*tcell.EventKey{key(tcell.KeyCtrlO), key(tcell.KeyDown), key(tcell.KeyDown), key(tcell.KeyEnter)},
+			wantIndices: []int{2},
+		},
+		{
+			name:        "enter on a header expands the group",
+			keys:        []*tcell.EventKey{key(tcell.KeyCtrlO), key(tcell.KeyEnter), key(tcell.KeyDown), key(tcell.KeyDown), key(tcell.KeyEnter)},
+			wantIndices: []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := runFinderWith(t, items, Options{}, tt.keys...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(result.Indices, tt.wantIndices) {
+				t.Errorf("Indices = %v, want %v", result.Indices, tt.wantIndices)
+			}
+		})
+	}
+}
+
+func TestNonInteractive(t *testing.T) {
+	items := []Item{
+		{Label: "services/capital"},
+		{Label: "services/api-gateway"},
+		{Label: "services/api", Keywords: func() []string { return []string{"edge", "backend"} }},
+	}
+	tests := []struct {
+		name        string
+		opts        Options
+		wantIndices []int
+		wantErr     bool
+	}{
+		{
+			name:        "first item without a query",
+			wantIndices: []int{0},
+		},
+		{
+			name:        "best match for the query",
+			opts:        Options{Query: "api", Matcher: match.Smart{}},
+			wantIndices: []int{2},
+		},
+		{
+			name:        "match by keyword",
+			opts:        Options{Query: "edge"},
+			wantIndices: []int{2},
+		},
+		{
+			name:        "preselected matches in multi mode",
+			opts:        Options{Query: "api", Multi: true, Preselected: func(i int) bool { return i != 2 }},
+			wantIndices: []int{0, 1},
+		},
+		{
+			name:    "nothing matches",
+			opts:    Options{Query: "zzz"},
+			wantErr: true,
+		},
+		{
+			name:    "filter error",
+			opts:    Options{Query: "bad:", Filter: testFilter},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NonInteractive(items, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NonInteractive() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !slices.Equal(result.Indices, tt.wantIndices) {
+				t.Errorf("Indices = %v, want %v", result.Indices, tt.wantIndices)
+			}
+		})
+	}
+}
+
+func TestFinder_InvalidKey(t *testing.T) {
+	if _, err := runFinder(t, Options{Keys: []string{"ctrl-shift-x"}}); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
+
+func TestNormalizeKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{"ctrl-e", "ctrl-e", false},
+		{"Ctrl+E", "ctrl-e", false},
+		{"alt-o", "alt-o", false},
+		{"alt-O", "alt-O", false},
+		{"alt-enter", "alt-enter", false},
+		{"f5", "f5", false},
+		{"shift-tab", "btab", false},
+		{"enter", "enter", false},
+		{"f13", "", true},
+		{"ctrl-1", "", true},
+		{"ctrl-i", "", true},
+		{"Ctrl+M", "", true},
+		{"ctrl-h", "", true},
+		{"hyper-x", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := NormalizeKey(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeKey(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeKey(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NormalizeKey("ctrl-i"); err == nil || !strings.Contains(err.Error(), "bind tab instead") {
+		t.Errorf("NormalizeKey(ctrl-i) error = %v, want the colliding key named", err)
+	}
+}
+
+func TestSgrStyle(t *testing.T) {
+	tests := []struct {
+		in     string
+		fg, bg tcell.Color
+	}{
+		{in: "\x1b[31;42mx", fg: tcell.ColorMaroon, bg: tcell.ColorGreen},
+		{in: "\x1b[91;102mx", fg: tcell.ColorRed, bg: tcell.ColorLime},
+		{in: "\x1b[97;100mx", fg: tcell.ColorWhite, bg: tcell.ColorGray},
+		{in: "\x1b[38;5;117mx", fg: tcell.PaletteColor(117), bg: tcell.ColorDefault},
+	}
+	for _, tt := range tests {
+		_, sgr, ok := ansisgr.NewIterator(tt.in).Next()
+		if !ok {
+			t.Fatalf("no rune in %q", tt.in)
+		}
+		fg, bg, _ := sgrStyle(sgr).Decompose()
+		if fg != tt.fg || bg != tt.bg {
+			t.Errorf("sgrStyle(%q) colors = %v, %v; want %v, %v", tt.in, fg, bg, tt.fg, tt.bg)
+		}
+	}
+}
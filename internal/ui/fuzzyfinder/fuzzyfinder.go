@@ -1,11 +1,18 @@
+// Package fuzzyfinder is the built-in finder. It ranks items with the
+// algorithm of go-fuzzyfinder and keeps its key bindings, but draws its own
+// tcell UI: go-fuzzyfinder has no way to bind extra keys or tell which key
+// ended a session, which key-bound actions need.
 package fuzzyfinder
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
-	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/gdamore/tcell/v2"
+	"github.com/yuya-takeyama/panama/internal/match"
 	"golang.org/x/term"
 )
 
@@ -13,159 +20,170 @@ type Item struct {
 	Label       string
 	Description string
 	Path        string
+	// Keywords returns other words the item is found by, such as aliases
+	// and tags. It is called the first time the query has text to match, so
+	// looking them up can wait until then. Items matching only by keyword
+	// rank after those matching by label.
+	Keywords func() []string
+	// Preview renders the preview pane for the item at the given size.
+	// When nil, the path and description are shown.
+	Preview func(width, height int) string
+	// Group, when set on any item, shows the items under a header per
+	// group, and Indent nests the item under its parents in the group
+	Group  string
+	Indent int
 }
 
-func Select(items []Item, query string) (int, error) {
+// Options configures a finder session
+type Options struct {
+	Prompt string
+	Query  string // Initial query
+	Header string // Line shown above the list, such as key binding hints
+	Multi  bool   // Allow selecting several items with Tab
+	// Preselected reports whether item i starts out selected in multi mode.
+	// Otherwise the cursor starts on the first preselected item. Defaults
+	// to the item for the current directory.
+	Preselected func(i int) bool
+	// Keys end the session when pressed; the key is reported in Result.Key
+	Keys []string
+	// Matcher ranks the items against the text of the query; defaults to
+	// match.Fuzzy
+	Matcher match.Matcher
+	// Filter, when set, interprets the query before fuzzy matching. It
+	// returns the items to consider and the text left to match against the
+	// labels. An error is shown in place of the match counter.
+	Filter func(query string) (keep func(i int) bool, text string, err error)
+}
+
+// Result is the outcome of a finder session
+type Result struct {
+	Indices []int  // Chosen items, in list order; empty when a bound key was pressed with nothing matching
+	Key     string // Bound key that ended the session, or "" for Enter
+	Query   string // Query when the session ended
+}
+
+// ErrAbort is returned when the user cancels the selection
+var ErrAbort = errors.New("selection cancelled")
+
+// newScreen opens the terminal; tests replace it with a simulation screen
+var newScreen = tcell.NewScreen
+
+// Find lets the user pick items with a fuzzy finder on the terminal. When
+// stdin is not a terminal it returns the outcome of NonInteractive.
+func Find(items []Item, opts Options) (*Result, error) {
 	if len(items) == 0 {
-		return -1, fmt.Errorf("no items to select from")
+		return nil, fmt.Errorf("no items to select from")
 	}
 
 	// TTY check
 	if !isTerminal() {
-		// Return first item in non-interactive mode
-		return 0, nil
+		return NonInteractive(items, opts)
 	}
 
-	// Get current working directory to preselect it
-	cwd, _ := os.Getwd()
-
-	opts := []fuzzyfinder.Option{
-		fuzzyfinder.WithPromptString("workspaces > "),
+	if opts.Preselected == nil {
+		// Get current working directory to preselect it
+		cwd, _ := os.Getwd()
+		opts.Preselected = func(i int) bool {
+			return items[i].Path == cwd
+		}
 	}
 
-	if query != "" {
-		opts = append(opts, fuzzyfinder.WithQuery(query))
+	screen, err := newScreen()
+	if err != nil {
+		return nil, err
 	}
-
-	// Add preselection for current directory
-	opts = append(opts, fuzzyfinder.WithPreselected(func(i int) bool {
-		if i < 0 || i >= len(items) {
-			return false
-		}
-		// Check if the item's path matches the current directory
-		return items[i].Path == cwd
-	}))
-
-	if len(items) > 0 && items[0].Description != "" {
-		opts = append(opts, fuzzyfinder.WithPreviewWindow(func(i, w, h int) string {
-			if i < 0 || i >= len(items) {
-				return ""
-			}
-			preview := fmt.Sprintf("Path: %s\n\n", items[i].Path)
-			if items[i].Description != "" {
-				preview += fmt.Sprintf("Description:\n%s", items[i].Description)
-			}
-			return wrapText(preview, w)
-		}))
+	if err := screen.Init(); err != nil {
+		return nil, err
 	}
+	defer screen.Fini()
 
-	idx, err := fuzzyfinder.Find(
-		items,
-		func(i int) string {
-			return items[i].Label
-		},
-		opts...,
-	)
-
+	f, err := newFinder(screen, items, opts)
 	if err != nil {
-		if err == fuzzyfinder.ErrAbort {
-			return -1, fmt.Errorf("selection cancelled")
-		}
-		return -1, err
+		return nil, err
 	}
-
-	return idx, nil
+	return f.run()
 }
 
-func SelectMulti(items []Item, query string) ([]int, error) {
-	if len(items) == 0 {
-		return nil, fmt.Errorf("no items to select from")
+// NonInteractive returns the outcome of a session without a terminal: the
+// preselected items matching the query in multi mode, or the best match
+func NonInteractive(items []Item, opts Options) (*Result, error) {
+	labels := make([]string, len(items))
+	for i, item := range items {
+		labels[i] = item.Label
 	}
-
-	if !isTerminal() {
-		// Return first item in non-interactive mode
-		return []int{0}, nil
+	keywords := func() []string { return joinKeywords(items) }
+	results, err := rank(labels, keywords, opts, opts.Query)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("nothing matches %q", opts.Query)
 	}
 
-	// Get current working directory to preselect it
-	cwd, _ := os.Getwd()
-
-	opts := []fuzzyfinder.Option{
-		fuzzyfinder.WithPromptString("workspaces > "),
+	result := &Result{Query: opts.Query}
+	if opts.Multi && opts.Preselected != nil {
+		for _, r := range results {
+			if opts.Preselected(r.Idx) {
+				result.Indices = append(result.Indices, r.Idx)
+			}
+		}
+		slices.Sort(result.Indices)
+	}
+	if len(result.Indices) == 0 {
+		result.Indices = []int{results[0].Idx}
 	}
+	return result, nil
+}
 
-	if query != "" {
-		opts = append(opts, fuzzyfinder.WithQuery(query))
+// rank returns the items matching query, best first, those matching only
+// by keyword after the others. keywords is called only when there is text
+// to match.
+func rank(labels []string, keywords func() []string, opts Options, query string) ([]match.Result, error) {
+	keep := func(int) bool { return true }
+	text := query
+	if opts.Filter != nil {
+		k, t, err := opts.Filter(query)
+		if err != nil {
+			return nil, err
+		}
+		if k != nil {
+			keep = k
+		}
+		text = t
 	}
 
-	// Add preselection for current directory
-	opts = append(opts, fuzzyfinder.WithPreselected(func(i int) bool {
-		if i < 0 || i >= len(items) {
-			return false
+	matcher := opts.Matcher
+	if matcher == nil {
+		matcher = match.Fuzzy{}
+	}
+	results := match.Rank(matcher, text, labels)
+	if strings.TrimSpace(text) != "" {
+		found := make(map[int]bool, len(results))
+		for _, r := range results {
+			found[r.Idx] = true
 		}
-		// Check if the item's path matches the current directory
-		return items[i].Path == cwd
-	}))
-
-	if len(items) > 0 && items[0].Description != "" {
-		opts = append(opts, fuzzyfinder.WithPreviewWindow(func(i, w, h int) string {
-			if i < 0 || i >= len(items) {
-				return ""
-			}
-			preview := fmt.Sprintf("Path: %s\n\n", items[i].Path)
-			if items[i].Description != "" {
-				preview += fmt.Sprintf("Description:\n%s", items[i].Description)
+		for _, r := range match.Rank(matcher, text, keywords()) {
+			if !found[r.Idx] {
+				// Nothing to highlight in the label
+				results = append(results, match.Result{Idx: r.Idx, Score: r.Score})
 			}
-			return wrapText(preview, w)
-		}))
+		}
 	}
+	return slices.DeleteFunc(results, func(r match.Result) bool { return !keep(r.Idx) }), nil
+}
 
-	idxs, err := fuzzyfinder.FindMulti(
-		items,
-		func(i int) string {
-			return items[i].Label
-		},
-		opts...,
-	)
-
-	if err != nil {
-		if err == fuzzyfinder.ErrAbort {
-			return nil, fmt.Errorf("selection cancelled")
+// joinKeywords returns the keywords of each item joined by spaces
+func joinKeywords(items []Item) []string {
+	words := make([]string, len(items))
+	for i, item := range items {
+		if item.Keywords != nil {
+			words[i] = strings.Join(item.Keywords(), " ")
 		}
-		return nil, err
 	}
-
-	return idxs, nil
+	return words
 }
 
 func isTerminal() bool {
-	// Check if stdin is a terminal (fuzzyfinder uses /dev/tty directly)
+	// Check if stdin is a terminal (the screen uses /dev/tty directly)
 	return term.IsTerminal(int(os.Stdin.Fd()))
 }
-
-func wrapText(text string, width int) string {
-	if width <= 0 {
-		return text
-	}
-
-	var result []string
-	lines := strings.Split(text, "\n")
-
-	for _, line := range lines {
-		if len(line) <= width {
-			result = append(result, line)
-			continue
-		}
-
-		// Simple word wrapping
-		for len(line) > width {
-			result = append(result, line[:width])
-			line = line[width:]
-		}
-		if len(line) > 0 {
-			result = append(result, line)
-		}
-	}
-
-	return strings.Join(result, "\n")
-}
@@ -0,0 +1,121 @@
+package fuzzyfinder
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// namedKeys maps key names, as written in the configuration, to tcell keys.
+// Names follow fzf: ctrl-x, alt-x, f1 to f12 and the names below.
+var namedKeys = map[string]tcell.Key{
+	"enter":      tcell.KeyEnter,
+	"tab":        tcell.KeyTab,
+	"btab":       tcell.KeyBacktab,
+	"esc":        tcell.KeyEscape,
+	"backspace":  tcell.KeyBackspace2,
+	"del":        tcell.KeyDelete,
+	"up":         tcell.KeyUp,
+	"down":       tcell.KeyDown,
+	"left":       tcell.KeyLeft,
+	"right":      tcell.KeyRight,
+	"home":       tcell.KeyHome,
+	"end":        tcell.KeyEnd,
+	"pgup":       tcell.KeyPgUp,
+	"pgdn":       tcell.KeyPgDn,
+	"ctrl-space": tcell.KeyCtrlSpace,
+}
+
+// keyAliases maps alternative spellings to the canonical key name
+var keyAliases = map[string]string{
+	"return":    "enter",
+	"shift-tab": "btab",
+	"escape":    "esc",
+	"bspace":    "backspace",
+	"bs":        "backspace",
+	"delete":    "del",
+	"page-up":   "pgup",
+	"page-down": "pgdn",
+}
+
+// controlCodes maps ctrl keys to the key terminals send the same code for,
+// so that binding them could never fire
+var controlCodes = map[string]string{
+	"ctrl-h": "backspace",
+	"ctrl-i": "tab",
+	"ctrl-m": "enter",
+}
+
+// NormalizeKey returns the canonical name of a key binding such as
+// "ctrl-e", "alt-o" or "f5", or an error when the key is unknown
+func NormalizeKey(key string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(key))
+	name = strings.ReplaceAll(name, "+", "-")
+	if alias, ok := keyAliases[name]; ok {
+		name = alias
+	}
+
+	if _, ok := namedKeys[name]; ok {
+		return name, nil
+	}
+
+	switch {
+	case strings.HasPrefix(name, "ctrl-") && len(name) == len("ctrl-")+1:
+		if same, ok := controlCodes[name]; ok {
+			return "", fmt.Errorf("key %q is indistinguishable from %s in a terminal; bind %s instead", key, same, same)
+		}
+		if c := name[len("ctrl-")]; c >= 'a' && c <= 'z' {
+			return name, nil
+		}
+	case strings.HasPrefix(name, "alt-"):
+		rest := strings.TrimSpace(key)[len("alt-"):]
+		if utf8.RuneCountInString(rest) == 1 {
+			// Keep the case of the character: alt-A and alt-a differ
+			return "alt-" + rest, nil
+		}
+		if _, ok := namedKeys[name[len("alt-"):]]; ok {
+			return name, nil
+		}
+	case strings.HasPrefix(name, "f"):
+		var n int
+		if _, err := fmt.Sscanf(name, "f%d", &n); err == nil && n >= 1 && n <= 12 && name == fmt.Sprintf("f%d", n) {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown key %q", key)
+}
+
+// keyName returns the canonical name of the key pressed in ev, or "" for
+// plain characters
+func keyName(ev *tcell.EventKey) string {
+	name := ""
+	switch key := ev.Key(); {
+	case key == tcell.KeyRune:
+		if ev.Modifiers()&tcell.ModAlt == 0 {
+			return ""
+		}
+		return "alt-" + string(ev.Rune())
+	case key == tcell.KeyBackspace:
+		name = "backspace"
+	case key >= tcell.KeyF1 && key <= tcell.KeyF12:
+		name = fmt.Sprintf("f%d", key-tcell.KeyF1+1)
+	default:
+		for n, k := range namedKeys {
+			if k == key {
+				name = n
+				break
+			}
+		}
+		if name == "" && key >= tcell.KeyCtrlA && key <= tcell.KeyCtrlZ {
+			name = "ctrl-" + string(rune('a'+key-tcell.KeyCtrlA))
+		}
+	}
+
+	if name != "" && ev.Modifiers()&tcell.ModAlt != 0 {
+		name = "alt-" + name
+	}
+	return name
+}
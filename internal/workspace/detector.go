@@ -20,15 +20,22 @@ func NewDetector(patterns []string) *Detector {
 
 // IsWorkspaceWithPatterns checks if a directory is a workspace with custom patterns
 func (d *Detector) IsWorkspaceWithPatterns(dir string) bool {
+	return d.Match(dir) != ""
+}
+
+// Match returns the rule that makes dir a workspace: ".git" for a Git
+// repository, or the first matching custom pattern. It returns an empty
+// string when dir is not a workspace.
+func (d *Detector) Match(dir string) string {
 	// Always check for .git directory
 	gitPath := filepath.Join(dir, ".git")
 	if info, err := os.Stat(gitPath); err == nil && info.IsDir() {
-		return true
+		return ".git"
 	}
 
 	// If no patterns configured, only .git directories are considered workspaces
 	if d == nil || len(d.customPatterns) == 0 {
-		return false
+		return ""
 	}
 
 	// Check custom patterns
@@ -38,18 +45,26 @@ func (d *Detector) IsWorkspaceWithPatterns(dir string) bool {
 			// Use glob matching
 			matches, err := filepath.Glob(filepath.Join(dir, pattern))
 			if err == nil && len(matches) > 0 {
-				return true
+				return pattern
 			}
 		} else {
 			// Simple file existence check
 			path := filepath.Join(dir, pattern)
 			if _, err := os.Stat(path); err == nil {
-				return true
+				return pattern
 			}
 		}
 	}
 
-	return false
+	return ""
+}
+
+// Patterns returns the custom patterns used by the detector
+func (d *Detector) Patterns() []string {
+	if d == nil {
+		return nil
+	}
+	return d.customPatterns
 }
 
 // IsWorkspace checks if a directory is a workspace using default patterns
@@ -114,3 +114,57 @@ func TestGetPackageType(t *testing.T) {
 		})
 	}
 }
+
+func TestDetector_Match(t *testing.T) {
+	tests := []struct {
+		name      string
+		patterns  []string
+		setupFunc func(dir string) error
+		want      string
+	}{
+		{
+			name:     ".git takes precedence",
+			patterns: []string{"go.mod"},
+			setupFunc: func(dir string) error {
+				if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+					return err
+				}
+				return os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0644)
+			},
+			want: ".git",
+		},
+		{
+			name:     "first matching pattern",
+			patterns: []string{"package.json", "*.csproj", "go.mod"},
+			setupFunc: func(dir string) error {
+				if err := os.WriteFile(filepath.Join(dir, "App.csproj"), []byte(""), 0644); err != nil {
+					return err
+				}
+				return os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0644)
+			},
+			want: "*.csproj",
+		},
+		{
+			name:     "no match",
+			patterns: []string{"package.json"},
+			setupFunc: func(dir string) error {
+				return nil
+			},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := tt.setupFunc(dir); err != nil {
+				t.Fatalf("setup failed: %v", err)
+			}
+
+			got := NewDetector(tt.patterns).Match(dir)
+			if got != tt.want {
+				t.Errorf("Match() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,10 +6,42 @@ import (
 )
 
 type Workspace struct {
-	Path        string `json:"path"`
-	Name        string `json:"name"`
-	Description string `json:"description,omitempty"`
-	Depth       int    `json:"depth"`
+	Path        string            `json:"path"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Depth       int               `json:"depth"`
+	Type        string            `json:"type,omitempty"`    // Package type, such as go or node
+	Owners      []string          `json:"owners,omitempty"`  // Teams or people owning the workspace
+	Tags        []string          `json:"tags,omitempty"`    // Labels used to group and filter workspaces
+	Aliases     []string          `json:"aliases,omitempty"` // Other names the workspace is known by
+	Editor      string            `json:"editor,omitempty"`  // Preferred editor command
+	Meta        map[string]string `json:"meta,omitempty"`    // Custom key/value pairs
+	Tasks       []Task            `json:"tasks,omitempty"`   // Scripts and targets defined in the workspace
+}
+
+// Details are the parts of a workspace read from files other than its
+// manifest. Collecting workspaces leaves them out, and they are filled in
+// where they are used.
+type Details uint8
+
+const (
+	DetailTasks    Details = 1 << iota // Tasks from package.json, Makefile and the like
+	DetailOwners                       // Owners from CODEOWNERS and metadata
+	DetailMetadata                     // Description, tags, aliases, editor and meta
+
+	AllDetails = DetailTasks | DetailOwners | DetailMetadata
+)
+
+// Describer fills in the details of workspaces
+type Describer interface {
+	Describe(ws *Workspace, details Details) error
+}
+
+// Task is a script, target or recipe that can be run in a workspace
+type Task struct {
+	Name    string `json:"name"`
+	Source  string `json:"source"`  // File defining the task, such as package.json
+	Command string `json:"command"` // Shell command running the task
 }
 
 func (w *Workspace) Label() string {